@@ -0,0 +1,13 @@
+// Package main implements the buf-plugin-entity-fields-breaking plugin, see the entityfieldsbreaking
+// package for its rules and options.
+package main
+
+import (
+	"buf.build/go/bufplugin/check"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/pkg/entityfieldsbreaking"
+)
+
+func main() {
+	check.Main(entityfieldsbreaking.Spec)
+}
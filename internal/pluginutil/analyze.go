@@ -0,0 +1,84 @@
+package pluginutil
+
+import (
+	"context"
+	"fmt"
+
+	descriptorv1 "buf.build/gen/go/bufbuild/bufplugin/protocolbuffers/go/buf/plugin/descriptor/v1"
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/descriptor"
+	"buf.build/go/bufplugin/option"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Violation is an issue reported by a rule when analyzing a set of files.
+// Lines and columns are zero-indexed, as in the source locations of the files.
+type Violation struct {
+	RuleID      string
+	Message     string
+	FileName    string
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
+// Analyze runs all of the lint rules of the given specs against a file
+// descriptor set, in-process and without the buf CLI, and returns the
+// violations they report. The options are the same ones configured for the
+// plugins in buf.yaml (e.g: profile or required_entity_fields), and are passed
+// as is to all of the specs.
+// Breaking rules aren't run, as they need a previous version of the files.
+func Analyze(ctx context.Context, fileDescriptorSet *descriptorpb.FileDescriptorSet, options map[string]any, specs ...*check.Spec) ([]Violation, error) {
+	protoFileDescriptors := make([]*descriptorv1.FileDescriptor, 0, len(fileDescriptorSet.GetFile()))
+	for _, fileDescriptorProto := range fileDescriptorSet.GetFile() {
+		protoFileDescriptors = append(protoFileDescriptors, &descriptorv1.FileDescriptor{FileDescriptorProto: fileDescriptorProto})
+	}
+	fileDescriptors, err := descriptor.FileDescriptorsForProtoFileDescriptors(protoFileDescriptors)
+	if err != nil {
+		return nil, err
+	}
+	requestOptions, err := option.NewOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, spec := range specs {
+		var ruleIDs []string
+		for _, ruleSpec := range spec.Rules {
+			if ruleSpec.Type == check.RuleTypeLint {
+				ruleIDs = append(ruleIDs, ruleSpec.ID)
+			}
+		}
+		if len(ruleIDs) == 0 {
+			continue
+		}
+		client, err := check.NewClientForSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		request, err := check.NewRequest(fileDescriptors, check.WithOptions(requestOptions), check.WithRuleIDs(ruleIDs...))
+		if err != nil {
+			return nil, err
+		}
+		response, err := client.Check(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run rules %v: %w", ruleIDs, err)
+		}
+		for _, annotation := range response.Annotations() {
+			violation := Violation{
+				RuleID:  annotation.RuleID(),
+				Message: annotation.Message(),
+			}
+			if fileLocation := annotation.FileLocation(); fileLocation != nil {
+				violation.FileName = fileLocation.FileDescriptor().ProtoreflectFileDescriptor().Path()
+				violation.StartLine = fileLocation.StartLine()
+				violation.StartColumn = fileLocation.StartColumn()
+				violation.EndLine = fileLocation.EndLine()
+				violation.EndColumn = fileLocation.EndColumn()
+			}
+			violations = append(violations, violation)
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,88 @@
+package pluginutil
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checkutil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	spec := &check.Spec{
+		Rules: []*check.RuleSpec{
+			{
+				ID:      "TEST_EMPTY_MESSAGES",
+				Default: false,
+				Purpose: "Checks that messages define at least one field.",
+				Type:    check.RuleTypeLint,
+				Handler: checkutil.NewMessageRuleHandler(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, messageDescriptor protoreflect.MessageDescriptor) error {
+					if messageDescriptor.Fields().Len() == 0 {
+						responseWriter.AddAnnotation(
+							check.WithMessagef("message %q is empty", messageDescriptor.Name()),
+							check.WithDescriptor(messageDescriptor),
+						)
+					}
+					return nil
+				}),
+			},
+			{
+				ID:      "TEST_BREAKING",
+				Default: true,
+				Purpose: "Checks for breaking changes.",
+				Type:    check.RuleTypeBreaking,
+				Handler: check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+					responseWriter.AddAnnotation(check.WithMessage("breaking change"))
+					return nil
+				}),
+			},
+		},
+	}
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("simple.proto"),
+				Package: proto.String("simple"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Book"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("id"),
+								JsonName: proto.String("id"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+						},
+					},
+					{
+						Name: proto.String("Author"),
+					},
+				},
+			},
+		},
+	}
+
+	violations, err := Analyze(context.Background(), fileDescriptorSet, nil, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Violation{
+		{
+			RuleID:   "TEST_EMPTY_MESSAGES",
+			Message:  "message \"Author\" is empty",
+			FileName: "simple.proto",
+		},
+	}
+	if !slices.Equal(violations, expected) {
+		t.Errorf("Analyze() = %+v, expected %+v", violations, expected)
+	}
+}
@@ -0,0 +1,54 @@
+package pluginutil
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	pluralize "github.com/gertd/go-pluralize"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	crudMethodPrefixes = []string{"List", "Get", "Delete", "Update", "Create"}
+	// EntityNameRegexp matches the CamelCase name of an entity (e.g: BookCategory).
+	EntityNameRegexp = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+)
+
+// InferEntityFromMethodName extracts the entity name by stripping CRUD prefixes,
+// or any of the given lifecycle prefixes (e.g: Restore).
+// The prefix must be followed by a CamelCase name, e.g: Listen doesn't refer to
+// an "en" entity.
+func InferEntityFromMethodName(methodName string, lifecyclePrefixes ...string) string {
+	p := pluralize.NewClient()
+	for _, prefix := range slices.Concat(crudMethodPrefixes, lifecyclePrefixes) {
+		if strings.HasPrefix(methodName, prefix) {
+			entityName := strings.TrimPrefix(methodName, prefix)
+			if !EntityNameRegexp.MatchString(entityName) {
+				return ""
+			}
+			return p.Singular(entityName)
+		}
+	}
+	return ""
+}
+
+// IsEntityMessage checks if the given message is an entity of its file, i.e:
+// a top-level message named after the CRUD methods of the services in the
+// same file (e.g: Book for GetBook).
+func IsEntityMessage(messageDescriptor protoreflect.MessageDescriptor) bool {
+	fileDescriptor, ok := messageDescriptor.Parent().(protoreflect.FileDescriptor)
+	if !ok {
+		return false
+	}
+	services := fileDescriptor.Services()
+	for i := range services.Len() {
+		methods := services.Get(i).Methods()
+		for j := range methods.Len() {
+			if InferEntityFromMethodName(string(methods.Get(j).Name())) == string(messageDescriptor.Name()) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package pluginutil
+
+import (
+	"testing"
+)
+
+func TestInferEntityFromMethodName(t *testing.T) {
+	t.Parallel()
+
+	for methodName, expected := range map[string]string{
+		"ListBooks":          "Book",
+		"GetBook":            "Book",
+		"CreateBookCategory": "BookCategory",
+		"Get":                "",
+		"List":               "",
+		"Listen":             "",
+		"Getter":             "",
+		"Created":            "",
+		"HelloWorld":         "",
+	} {
+		if actual := InferEntityFromMethodName(methodName); actual != expected {
+			t.Errorf("InferEntityFromMethodName(%q) = %q, expected %q", methodName, actual, expected)
+		}
+	}
+	if actual := InferEntityFromMethodName("RestoreBook", "Restore"); actual != "Book" {
+		t.Errorf("InferEntityFromMethodName(%q) = %q, expected %q", "RestoreBook", actual, "Book")
+	}
+}
+
+func FuzzInferEntityFromMethodName(f *testing.F) {
+	for _, seed := range []string{"", "List", "Get", "Listen", "Getter", "Created", "Creates", "ListBooks", "DeleteBookCategory", "ListÄpfel", "Get日本語", "Update_", "Create\x00"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, methodName string) {
+		entityName := InferEntityFromMethodName(methodName)
+		if entityName != "" && !EntityNameRegexp.MatchString(entityName) {
+			t.Errorf("InferEntityFromMethodName(%q) = %q, expected an empty string or a valid entity name", methodName, entityName)
+		}
+	})
+}
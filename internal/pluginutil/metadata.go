@@ -0,0 +1,75 @@
+package pluginutil
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/option"
+)
+
+// EmitRuleMetadataOptionKey is the option key to make a plugin emit the
+// metadata of its rules and options as a JSON annotation, for tooling to
+// enumerate them.
+const EmitRuleMetadataOptionKey = "emit_rule_metadata"
+
+// PluginMetadata describes the rules of a plugin and the option keys it
+// recognizes.
+type PluginMetadata struct {
+	Rules   []RuleMetadata `json:"rules"`
+	Options []string       `json:"options"`
+}
+
+// RuleMetadata describes a rule of a plugin.
+type RuleMetadata struct {
+	ID      string `json:"id"`
+	Purpose string `json:"purpose"`
+	Default bool   `json:"default"`
+	Type    string `json:"type"`
+}
+
+// GetPluginMetadata returns the metadata of the rules of the given spec, along
+// with the given option keys and the ones shared by all of the plugins, sorted.
+func GetPluginMetadata(spec *check.Spec, optionKeys ...string) PluginMetadata {
+	metadata := PluginMetadata{
+		Rules:   make([]RuleMetadata, 0, len(spec.Rules)),
+		Options: slices.Sorted(slices.Values(slices.Concat(sharedOptionKeys, optionKeys))),
+	}
+	for _, ruleSpec := range spec.Rules {
+		metadata.Rules = append(metadata.Rules, RuleMetadata{
+			ID:      ruleSpec.ID,
+			Purpose: ruleSpec.Purpose,
+			Default: ruleSpec.Default,
+			Type:    ruleSpec.Type.String(),
+		})
+	}
+	return metadata
+}
+
+// WithRuleMetadata wraps the handlers of all the rules in the given spec, so
+// when the EmitRuleMetadataOptionKey option is enabled, an annotation is added
+// with the JSON encoded metadata of the plugin (see GetPluginMetadata). The
+// annotation is only added by the first rule which runs, to not repeat it for
+// each rule.
+func WithRuleMetadata(spec *check.Spec, optionKeys ...string) *check.Spec {
+	for _, ruleSpec := range spec.Rules {
+		ruleID := ruleSpec.ID
+		handler := ruleSpec.Handler
+		ruleSpec.Handler = check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+			emitRuleMetadata, err := option.GetBoolValue(request.Options(), EmitRuleMetadataOptionKey)
+			if err != nil {
+				return err
+			}
+			if emitRuleMetadata && ruleID == firstRuleID(spec, request) {
+				metadata, err := json.Marshal(GetPluginMetadata(spec, optionKeys...))
+				if err != nil {
+					return err
+				}
+				responseWriter.AddAnnotation(check.WithMessage(string(metadata)))
+			}
+			return handler.Handle(ctx, responseWriter, request)
+		})
+	}
+	return spec
+}
@@ -0,0 +1,92 @@
+package pluginutil
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"buf.build/go/bufplugin/check"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestWithRuleMetadata(t *testing.T) {
+	t.Parallel()
+
+	noopHandler := check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+		return nil
+	})
+	spec := WithRuleMetadata(WithStrictOptions(&check.Spec{
+		Rules: []*check.RuleSpec{
+			{
+				ID:      "TEST_FIRST",
+				Default: true,
+				Purpose: "Checks the first thing.",
+				Type:    check.RuleTypeLint,
+				Handler: noopHandler,
+			},
+			{
+				ID:      "TEST_SECOND",
+				Default: false,
+				Purpose: "Checks the second thing.",
+				Type:    check.RuleTypeLint,
+				Handler: noopHandler,
+			},
+		},
+	}, "test_option"), "test_option")
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("simple.proto"),
+				Package: proto.String("simple"),
+				Syntax:  proto.String("proto3"),
+			},
+		},
+	}
+
+	violations, err := Analyze(context.Background(), fileDescriptorSet, nil, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("Analyze() without %s = %+v, expected no violations", EmitRuleMetadataOptionKey, violations)
+	}
+
+	options := map[string]any{
+		EmitRuleMetadataOptionKey: true,
+		StrictOptionsOptionKey:    true,
+	}
+	violations, err = Analyze(context.Background(), fileDescriptorSet, options, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 {
+		t.Fatalf("Analyze() with %s = %+v, expected a single violation", EmitRuleMetadataOptionKey, violations)
+	}
+	var metadata PluginMetadata
+	if err := json.Unmarshal([]byte(violations[0].Message), &metadata); err != nil {
+		t.Fatalf("failed to parse rule metadata %q: %v", violations[0].Message, err)
+	}
+	var ruleIDs []string
+	for _, rule := range metadata.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	expectedRuleIDs := []string{"TEST_FIRST", "TEST_SECOND"}
+	if !slices.Equal(ruleIDs, expectedRuleIDs) {
+		t.Errorf("rule IDs = %v, expected %v", ruleIDs, expectedRuleIDs)
+	}
+	expectedRule := RuleMetadata{
+		ID:      "TEST_SECOND",
+		Purpose: "Checks the second thing.",
+		Default: false,
+		Type:    "lint",
+	}
+	if metadata.Rules[1] != expectedRule {
+		t.Errorf("rule metadata = %+v, expected %+v", metadata.Rules[1], expectedRule)
+	}
+	expectedOptions := []string{EmitRuleMetadataOptionKey, StrictOptionsOptionKey, "test_option"}
+	if !slices.Equal(metadata.Options, expectedOptions) {
+		t.Errorf("options = %v, expected %v", metadata.Options, expectedOptions)
+	}
+}
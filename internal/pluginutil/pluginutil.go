@@ -0,0 +1,261 @@
+// Package pluginutil implements helpers shared by the Qdrant Cloud Buf plugins.
+package pluginutil
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/option"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// DisableRulesEnvVar is the environment variable with a comma-separated list
+// of rule IDs to disable (e.g: QDRANT_CLOUD_METHOD_OPTIONS,QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS).
+// It's meant for ephemeral debugging (e.g: in CI), and takes precedence over
+// the rules enabled in the buf configuration.
+const DisableRulesEnvVar = "QDRANT_BUF_DISABLE_RULES"
+
+// ProfileOptionKey is the option key to select a profile (e.g: strict or
+// lenient), which seeds the defaults of the other options of a plugin.
+const ProfileOptionKey = "profile"
+
+// StrictOptionsOptionKey is the option key to enable the strict options mode,
+// which reports the option keys unknown to a plugin (e.g: misspelled ones),
+// instead of silently ignoring them.
+const StrictOptionsOptionKey = "strict_options"
+
+// PermissionsBreakingExemptExtensionName is the full name of the method option
+// exempting a method from the permissions breaking checks. The exemption must
+// be justified with a leading comment of the method, see
+// PermissionsBreakingExemptCommentPrefix.
+const PermissionsBreakingExemptExtensionName = "qdrant.cloud.common.v1.permissions_breaking_exempt"
+
+// PermissionsBreakingExemptCommentPrefix is the prefix of the comment which
+// justifies a permissions breaking exemption (e.g: // Exempt because: ...).
+const PermissionsBreakingExemptCommentPrefix = "Exempt because:"
+
+// sharedOptionKeys are the option keys recognized by all of the plugins. The
+// ProfileOptionKey option is only recognized by the plugins defining profiles,
+// which list it in their own option keys.
+var sharedOptionKeys = []string{StrictOptionsOptionKey, EmitRuleMetadataOptionKey}
+
+// Profiles maps the name of each profile to its bundle of option defaults.
+type Profiles map[string]map[string]any
+
+// WithDisableRulesEnv wraps the handlers of all the rules in the given spec,
+// so rules disabled with the DisableRulesEnvVar environment variable return
+// early without adding any annotation.
+func WithDisableRulesEnv(spec *check.Spec) *check.Spec {
+	for _, ruleSpec := range spec.Rules {
+		ruleID := ruleSpec.ID
+		handler := ruleSpec.Handler
+		ruleSpec.Handler = check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+			if IsRuleDisabled(ruleID) {
+				return nil
+			}
+			return handler.Handle(ctx, responseWriter, request)
+		})
+	}
+	return spec
+}
+
+// WithProfiles wraps the handlers of all the rules in the given spec, so the
+// options of the profile selected with the ProfileOptionKey option are used
+// as defaults for the options of the request.
+func WithProfiles(spec *check.Spec, profiles Profiles) *check.Spec {
+	for _, ruleSpec := range spec.Rules {
+		handler := ruleSpec.Handler
+		ruleSpec.Handler = check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+			request, err := ResolveProfile(request, profiles)
+			if err != nil {
+				return err
+			}
+			return handler.Handle(ctx, responseWriter, request)
+		})
+	}
+	return spec
+}
+
+// WithStrictOptions wraps the handlers of all the rules in the given spec, so
+// when the StrictOptionsOptionKey option is enabled, an annotation is added for
+// each option key which isn't one of the given ones. The annotations are only
+// added by the first rule which runs, to not repeat them for each rule.
+func WithStrictOptions(spec *check.Spec, optionKeys ...string) *check.Spec {
+	knownOptionKeys := slices.Concat(sharedOptionKeys, optionKeys)
+	for _, ruleSpec := range spec.Rules {
+		ruleID := ruleSpec.ID
+		handler := ruleSpec.Handler
+		ruleSpec.Handler = check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+			strictOptions, err := option.GetBoolValue(request.Options(), StrictOptionsOptionKey)
+			if err != nil {
+				return err
+			}
+			if strictOptions && ruleID == firstRuleID(spec, request) {
+				for _, key := range UnknownOptionKeys(request.Options(), knownOptionKeys) {
+					responseWriter.AddAnnotation(check.WithMessagef("unknown option %q", key))
+				}
+			}
+			return handler.Handle(ctx, responseWriter, request)
+		})
+	}
+	return spec
+}
+
+// UnknownOptionKeys returns the sorted keys of the given options which aren't
+// part of the known ones.
+func UnknownOptionKeys(options option.Options, knownOptionKeys []string) []string {
+	var unknownKeys []string
+	options.Range(func(key string, _ any) {
+		if !slices.Contains(knownOptionKeys, key) {
+			unknownKeys = append(unknownKeys, key)
+		}
+	})
+	slices.Sort(unknownKeys)
+	return unknownKeys
+}
+
+// firstRuleID returns the ID of the first rule of the spec which runs for the
+// given request: either one of the requested rules, or of the default ones if
+// no rules are requested.
+func firstRuleID(spec *check.Spec, request check.Request) string {
+	for _, ruleSpec := range spec.Rules {
+		if slices.Contains(request.RuleIDs(), ruleSpec.ID) || (len(request.RuleIDs()) == 0 && ruleSpec.Default) {
+			return ruleSpec.ID
+		}
+	}
+	return ""
+}
+
+// ResolveProfile returns the given request, with the options of the selected
+// profile seeded as defaults. Options set in the request override the ones of
+// the profile. The request is returned as is if no profile is selected.
+func ResolveProfile(request check.Request, profiles Profiles) (check.Request, error) {
+	profile, err := option.GetStringValue(request.Options(), ProfileOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return request, nil
+	}
+	profileOptions, found := profiles[profile]
+	if !found {
+		return nil, fmt.Errorf("unknown %s option value %q, expected one of %v", ProfileOptionKey, profile, slices.Sorted(maps.Keys(profiles)))
+	}
+	keyToValue := make(map[string]any)
+	maps.Copy(keyToValue, profileOptions)
+	request.Options().Range(func(key string, value any) {
+		keyToValue[key] = value
+	})
+	options, err := option.NewOptions(keyToValue)
+	if err != nil {
+		return nil, err
+	}
+	return check.NewRequest(
+		request.FileDescriptors(),
+		check.WithAgainstFileDescriptors(request.AgainstFileDescriptors()),
+		check.WithOptions(options),
+		check.WithRuleIDs(request.RuleIDs()...),
+	)
+}
+
+// IsRuleRunning checks if the given rule runs for the given request: it's
+// either one of the requested rules, or a default one if no rules are
+// requested, and it isn't disabled with the DisableRulesEnvVar environment
+// variable. This allows overlapping rules to not report the same issue twice.
+func IsRuleRunning(request check.Request, ruleSpec *check.RuleSpec) bool {
+	if IsRuleDisabled(ruleSpec.ID) {
+		return false
+	}
+	if len(request.RuleIDs()) == 0 {
+		return ruleSpec.Default
+	}
+	return slices.Contains(request.RuleIDs(), ruleSpec.ID)
+}
+
+// IsRuleDisabled checks if the given rule ID is disabled with the
+// DisableRulesEnvVar environment variable.
+func IsRuleDisabled(ruleID string) bool {
+	disabledRules := strings.Split(os.Getenv(DisableRulesEnvVar), ",")
+	return slices.ContainsFunc(disabledRules, func(disabledRule string) bool {
+		return strings.TrimSpace(disabledRule) == ruleID
+	})
+}
+
+// FindExtension returns the extension with the given full name (e.g:
+// qdrant.cloud.common.v1.internal_only), resolved from the files of the given
+// request. This allows reading custom options which aren't part of the Go
+// dependencies of the plugins. Returns nil if the extension isn't found.
+func FindExtension(request check.Request, fullName protoreflect.FullName) protoreflect.ExtensionType {
+	for _, fileDescriptor := range request.FileDescriptors() {
+		extensions := fileDescriptor.ProtoreflectFileDescriptor().Extensions()
+		if extension := extensions.ByName(fullName.Name()); extension != nil && extension.FullName() == fullName {
+			return dynamicpb.NewExtensionType(extension)
+		}
+	}
+	return nil
+}
+
+// GetBoolExtension returns the value of the bool extension with the given full
+// name, set in the given options (e.g: the options of a method). Returns false
+// if the extension can't be resolved or isn't set.
+func GetBoolExtension(request check.Request, options proto.Message, fullName protoreflect.FullName) (bool, error) {
+	value, found, err := getExtensionValue(request, options, fullName, protoreflect.BoolKind, false)
+	if err != nil || !found {
+		return false, err
+	}
+	return value.Bool(), nil
+}
+
+// GetStringSliceExtension returns the values of the repeated string extension
+// with the given full name, set in the given options (e.g: the options of a
+// file). Returns nil if the extension can't be resolved or isn't set.
+func GetStringSliceExtension(request check.Request, options proto.Message, fullName protoreflect.FullName) ([]string, error) {
+	value, found, err := getExtensionValue(request, options, fullName, protoreflect.StringKind, true)
+	if err != nil || !found {
+		return nil, err
+	}
+	list := value.List()
+	values := make([]string, 0, list.Len())
+	for i := range list.Len() {
+		values = append(values, list.Get(i).String())
+	}
+	return values, nil
+}
+
+// getExtensionValue returns the value of the extension with the given full
+// name, set in the given options, if it can be resolved, has the given kind
+// and cardinality, and is set.
+func getExtensionValue(request check.Request, options proto.Message, fullName protoreflect.FullName, kind protoreflect.Kind, repeated bool) (protoreflect.Value, bool, error) {
+	extension := FindExtension(request, fullName)
+	if extension == nil || extension.TypeDescriptor().Kind() != kind || extension.TypeDescriptor().IsList() != repeated {
+		return protoreflect.Value{}, false, nil
+	}
+	// The options are re-parsed with the resolved extension, as it's stored as
+	// an unknown field when it isn't known at the time the options are parsed.
+	// The options message is built from the descriptor the extension belongs
+	// to, as the extension can't be read from a message with another descriptor.
+	data, err := proto.Marshal(options)
+	if err != nil {
+		return protoreflect.Value{}, false, err
+	}
+	types := new(protoregistry.Types)
+	if err := types.RegisterExtension(extension); err != nil {
+		return protoreflect.Value{}, false, err
+	}
+	resolvedOptions := dynamicpb.NewMessage(extension.TypeDescriptor().ContainingMessage())
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, resolvedOptions); err != nil {
+		return protoreflect.Value{}, false, err
+	}
+	if !resolvedOptions.Has(extension.TypeDescriptor()) {
+		return protoreflect.Value{}, false, nil
+	}
+	return resolvedOptions.Get(extension.TypeDescriptor()), true, nil
+}
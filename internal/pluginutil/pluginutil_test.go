@@ -0,0 +1,111 @@
+package pluginutil
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checktest"
+	"buf.build/go/bufplugin/option"
+)
+
+func TestIsRuleDisabled(t *testing.T) {
+	t.Setenv(DisableRulesEnvVar, "QDRANT_CLOUD_METHOD_OPTIONS, QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS")
+
+	for ruleID, expected := range map[string]bool{
+		"QDRANT_CLOUD_METHOD_OPTIONS":          true,
+		"QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS": true,
+		"QDRANT_CLOUD_REQUIRED_ENTITY_FIELDS":  false,
+		"QDRANT_CLOUD_METHOD":                  false,
+		"":                                     false,
+	} {
+		if actual := IsRuleDisabled(ruleID); actual != expected {
+			t.Errorf("IsRuleDisabled(%q) = %t, expected %t", ruleID, actual, expected)
+		}
+	}
+}
+
+func TestIsRuleRunning(t *testing.T) {
+	t.Parallel()
+
+	defaultRuleSpec := &check.RuleSpec{ID: "TEST_DEFAULT", Default: true}
+	optionalRuleSpec := &check.RuleSpec{ID: "TEST_OPTIONAL", Default: false}
+	for _, ruleIDs := range [][]string{nil, {"TEST_OPTIONAL"}} {
+		request, err := (&checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: ruleIDs,
+		}).ToRequest(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := len(ruleIDs) == 0
+		if actual := IsRuleRunning(request, defaultRuleSpec); actual != expected {
+			t.Errorf("IsRuleRunning(%v, %q) = %t, expected %t", ruleIDs, defaultRuleSpec.ID, actual, expected)
+		}
+		if actual := IsRuleRunning(request, optionalRuleSpec); actual != !expected {
+			t.Errorf("IsRuleRunning(%v, %q) = %t, expected %t", ruleIDs, optionalRuleSpec.ID, actual, !expected)
+		}
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	t.Parallel()
+
+	profiles := Profiles{
+		"strict": {
+			"max_fields":  int64(10),
+			"require_all": true,
+		},
+	}
+	request, err := (&checktest.RequestSpec{
+		Files: &checktest.ProtoFileSpec{
+			DirPaths:  []string{"testdata"},
+			FilePaths: []string{"simple.proto"},
+		},
+		Options: map[string]any{
+			ProfileOptionKey: "strict",
+			"max_fields":     int64(5),
+		},
+	}).ToRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolvedRequest, err := ResolveProfile(request, profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Options set in the request override the ones of the profile.
+	if maxFields, err := option.GetInt64Value(resolvedRequest.Options(), "max_fields"); err != nil || maxFields != 5 {
+		t.Errorf("max_fields = %d (%v), expected 5", maxFields, err)
+	}
+	if requireAll, err := option.GetBoolValue(resolvedRequest.Options(), "require_all"); err != nil || !requireAll {
+		t.Errorf("require_all = %t (%v), expected true", requireAll, err)
+	}
+
+	if _, err := ResolveProfile(request, Profiles{"lenient": {}}); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestUnknownOptionKeys(t *testing.T) {
+	t.Parallel()
+
+	options, err := option.NewOptions(map[string]any{
+		"requiredentityfields": []string{"id"},
+		"require_etag":         true,
+		ProfileOptionKey:       "strict",
+		"max_fields":           int64(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownKeys := UnknownOptionKeys(options, []string{ProfileOptionKey, "require_etag"})
+	if expected := []string{"max_fields", "requiredentityfields"}; !slices.Equal(unknownKeys, expected) {
+		t.Errorf("UnknownOptionKeys() = %v, expected %v", unknownKeys, expected)
+	}
+}
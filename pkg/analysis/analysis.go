@@ -0,0 +1,38 @@
+// Package analysis runs the rules of all of the plugins in-process, without
+// the buf CLI, for tooling which already has the descriptors of the files.
+package analysis
+
+import (
+	"context"
+
+	"buf.build/go/bufplugin/check"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+	"github.com/qdrant/qdrant-cloud-buf-plugins/pkg/entityfieldsbreaking"
+	"github.com/qdrant/qdrant-cloud-buf-plugins/pkg/methodoptions"
+	"github.com/qdrant/qdrant-cloud-buf-plugins/pkg/permissionsbreaking"
+	"github.com/qdrant/qdrant-cloud-buf-plugins/pkg/requiredfields"
+)
+
+// Violation is an issue reported by a rule when analyzing a set of files.
+// Lines and columns are zero-indexed, as in the source locations of the files.
+type Violation = pluginutil.Violation
+
+// Specs are the check specs of all of the plugins.
+var Specs = []*check.Spec{
+	requiredfields.Spec,
+	methodoptions.Spec,
+	permissionsbreaking.Spec,
+	entityfieldsbreaking.Spec,
+}
+
+// Analyze runs the lint rules of all of the plugins against a file descriptor
+// set, and returns the violations they report. The options are the same ones
+// configured for the plugins in buf.yaml (e.g: profile or
+// required_entity_fields), and are passed as is to all of the plugins, so the
+// strict_options option can't be used.
+// Breaking rules aren't run, as they need a previous version of the files.
+func Analyze(ctx context.Context, fileDescriptorSet *descriptorpb.FileDescriptorSet, options map[string]any) ([]Violation, error) {
+	return pluginutil.Analyze(ctx, fileDescriptorSet, options, Specs...)
+}
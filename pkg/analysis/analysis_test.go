@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("simple.proto"),
+				Package: proto.String("simple"),
+				Syntax:  proto.String("proto3"),
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					{
+						Name: proto.String("BookService"),
+						Method: []*descriptorpb.MethodDescriptorProto{
+							{
+								Name:       proto.String("GetBook"),
+								InputType:  proto.String(".simple.GetBookRequest"),
+								OutputType: proto.String(".simple.GetBookResponse"),
+							},
+						},
+					},
+				},
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("GetBookRequest"),
+					},
+					{
+						Name: proto.String("GetBookResponse"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("book"),
+								JsonName: proto.String("book"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".simple.Book"),
+							},
+						},
+					},
+					{
+						Name: proto.String("Book"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("name"),
+								JsonName: proto.String("name"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	violations, err := Analyze(context.Background(), fileDescriptorSet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Violation{
+		{
+			RuleID:   "QDRANT_CLOUD_ENTITY_RESOURCE_TYPE",
+			Message:  "entity \"Book\" is missing google.api.resource annotation",
+			FileName: "simple.proto",
+		},
+		{
+			RuleID:   "QDRANT_CLOUD_REQUIRED_ENTITY_FIELDS",
+			Message:  "message \"Book\" is missing required fields: [id account_id created_at]",
+			FileName: "simple.proto",
+		},
+		{
+			RuleID:   "QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS",
+			Message:  "message \"GetBookRequest\" is missing required fields: [account_id]",
+			FileName: "simple.proto",
+		},
+		{
+			RuleID:   "QDRANT_CLOUD_METHOD_OPTIONS",
+			Message:  "Method \"simple.BookService.GetBook\" does not define the \"google.api.http\" option",
+			FileName: "simple.proto",
+		},
+		{
+			RuleID:   "QDRANT_CLOUD_METHOD_OPTIONS",
+			Message:  "Method \"simple.BookService.GetBook\" does not define the \"qdrant.cloud.common.v1.permissions\" option",
+			FileName: "simple.proto",
+		},
+	}
+	if !slices.Equal(violations, expected) {
+		t.Errorf("Analyze() = %+v, expected %+v", violations, expected)
+	}
+}
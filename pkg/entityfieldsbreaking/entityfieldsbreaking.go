@@ -0,0 +1,86 @@
+// Package entityfieldsbreaking implements a plugin that checks for breaking changes in the
+// fields of entity-related messages (e.g: Cluster).
+//
+// Breaking changes detected:
+// - Changing the cardinality of a field, from singular to repeated or vice
+// versa (e.g: string tag = 1 to repeated string tags = 1). This breaks both
+// the wire and the client compatibility. It's only reported for the entity
+// messages, i.e: the top-level messages named after the CRUD methods of the
+// services in the same file (e.g: Book for GetBook).
+//
+// Fields are matched by their message and their number, so renaming the field
+// along with the cardinality change is also detected.
+//
+// To use this plugin:
+//
+//	# buf.yaml
+//	version: v2
+//	breaking:
+//	  use:
+//	   - QDRANT_CLOUD_FIELD_CARDINALITY_BREAKING
+//	plugins:
+//	  - plugin: buf-plugin-entity-fields-breaking
+package entityfieldsbreaking
+
+import (
+	"context"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checkutil"
+	"buf.build/go/bufplugin/info"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+const (
+	fieldCardinalityBreakingRuleID = "QDRANT_CLOUD_FIELD_CARDINALITY_BREAKING"
+)
+
+var (
+	fieldCardinalityBreakingRuleSpec = &check.RuleSpec{
+		ID:      fieldCardinalityBreakingRuleID,
+		Default: true,
+		Purpose: `Checks for fields of entity messages changing from singular to repeated or vice versa.`,
+		Type:    check.RuleTypeBreaking,
+		Handler: checkutil.NewFieldPairRuleHandler(checkFieldCardinalityBreaking, checkutil.WithoutImports()),
+	}
+	// Spec is the check spec of the plugin, served by its command and run
+	// in-process by the analysis package.
+	Spec = pluginutil.WithDisableRulesEnv(pluginutil.WithRuleMetadata(pluginutil.WithStrictOptions(&check.Spec{
+		Rules: []*check.RuleSpec{
+			fieldCardinalityBreakingRuleSpec,
+		},
+		Info: &info.Spec{
+			Documentation: `A plugin that checks for breaking changes in the fields of entity-related messages.`,
+			SPDXLicenseID: "",
+			LicenseURL:    "",
+		},
+	})))
+)
+
+func checkFieldCardinalityBreaking(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fieldDescriptor, againstFieldDescriptor protoreflect.FieldDescriptor) error {
+	if !pluginutil.IsEntityMessage(fieldDescriptor.ContainingMessage()) {
+		return nil
+	}
+	repeated := fieldDescriptor.Cardinality() == protoreflect.Repeated
+	againstRepeated := againstFieldDescriptor.Cardinality() == protoreflect.Repeated
+	if repeated == againstRepeated {
+		return nil
+	}
+	responseWriter.AddAnnotation(
+		check.WithMessagef("field %q changed from %s to %s", againstFieldDescriptor.Name(), cardinalityName(againstRepeated), cardinalityName(repeated)),
+		check.WithDescriptor(fieldDescriptor),
+		check.WithAgainstDescriptor(againstFieldDescriptor),
+	)
+
+	return nil
+}
+
+// cardinalityName returns the name of the cardinality of a field.
+func cardinalityName(repeated bool) string {
+	if repeated {
+		return "repeated"
+	}
+	return "singular"
+}
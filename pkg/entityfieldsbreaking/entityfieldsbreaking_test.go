@@ -0,0 +1,68 @@
+package entityfieldsbreaking
+
+import (
+	"testing"
+
+	"buf.build/go/bufplugin/check/checktest"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+	checktest.SpecTest(t, Spec)
+}
+
+func TestCardinalityChangeBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/cardinality_change/current"},
+				FilePaths: []string{"entity.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/cardinality_change/previous"},
+				FilePaths: []string{"entity.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  fieldCardinalityBreakingRuleID,
+				Message: "field \"tag\" changed from singular to repeated",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "entity.proto",
+					StartLine:   6,
+					StartColumn: 2,
+					EndLine:     6,
+					EndColumn:   27,
+				},
+				AgainstFileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "entity.proto",
+					StartLine:   6,
+					StartColumn: 2,
+					EndLine:     6,
+					EndColumn:   17,
+				},
+			},
+			{
+				RuleID:  fieldCardinalityBreakingRuleID,
+				Message: "field \"authors\" changed from repeated to singular",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "entity.proto",
+					StartLine:   7,
+					StartColumn: 2,
+					EndLine:     7,
+					EndColumn:   20,
+				},
+				AgainstFileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "entity.proto",
+					StartLine:   7,
+					StartColumn: 2,
+					EndLine:     7,
+					EndColumn:   30,
+				},
+			},
+		},
+	}.Run(t)
+}
@@ -0,0 +1,1735 @@
+// Package methodoptions implements a plugin that checks that all rpc methods set the
+// required options. The list of options is configurable.
+// The default value is:
+// - "qdrant.cloud.common.v1.permissions"
+// - "google.api.http"
+//
+// The account_id_expression option must reference a string field of the method
+// input message (e.g: cluster.account_id or request.account_id), rather than
+// hardcode an account id. Read methods (e.g: GetCluster) can't reference the
+// response (e.g: response.account_id), as they're authorized before it exists.
+//
+// Optionally, it type-checks the account_id_expression as a CEL expression,
+// with the request and response variables typed as the method input and output
+// messages, and the fields of the input message as top-level variables.
+//
+// Internal only methods (qdrant.cloud.common.v1.internal_only) don't need the
+// google.api.http option, and must not declare it as they aren't exposed
+// through a REST endpoint.
+//
+// Optionally, it reports internal only streaming methods (e.g: telemetry)
+// setting permissions, which are usually meaningless for them and copy-pasted
+// from another method.
+//
+// It also checks that the input and output messages of all rpc methods are
+// defined in the same package as the service. Messages from the packages in
+// the method_message_package_allowlist option are exempted.
+// The default value is:
+// - "google.protobuf"
+//
+// It also checks that the permissions of all rpc methods use a known verb
+// (e.g: "read" in "read:cluster"). The list of verbs is configurable with the
+// permission_verbs option.
+// The default value is: read, write, manage, delete, create
+//
+// It also checks that rpc methods with a permission verb mapped to method name
+// prefixes are named with one of them (e.g: a delete:cluster permission on
+// DeleteCluster rather than UpdateCluster). The mapping is configurable with the
+// permission_verb_prefixes option, with the format "verb=Prefix1,Prefix2".
+// The default value is: delete=Delete
+//
+// Optionally, it checks that rpc methods requiring all of their permissions pair a
+// verb with the verbs it implies on the same resource (e.g: write:cluster with
+// read:cluster). The pairs are configurable with the permission_verb_pairs
+// option, with the format "verb=impliedVerb1,impliedVerb2".
+// The default value is: write=read
+//
+// It also checks that the permissions of all rpc methods are declared in
+// sorted order (e.g: ["read:cluster", "write:cluster"]).
+//
+// It also checks that the permissions of all rpc methods are known, when the
+// known_permissions option is set. Unknown permissions close to a known one
+// (e.g: "raed:cluster") get a suggestion of the intended one.
+//
+// It also checks that the variables of the google.api.http path templates
+// (e.g: {cluster_id}) reference existing fields of the method input message.
+//
+// It also checks that the identifiers bound to the http path (e.g: {cluster_id})
+// aren't duplicated by a message field of the method input message (e.g: a
+// cluster field embedding its own cluster_id).
+//
+// It also checks that methods with side effects (e.g: DeleteCluster) aren't
+// bound to the http GET method, which must be safe and idempotent.
+//
+// It also checks that the version segment of the google.api.http paths (e.g:
+// v1 in /api/cluster/v1/clusters) matches the version of the proto package
+// (e.g: qdrant.cloud.cluster.v1), when both are versioned.
+//
+// Optionally, it checks that server streaming methods with a google.api.http
+// binding set its response_body. Disabled by default, the rule also needs the
+// check_streaming_response_body option.
+//
+// The http checks also apply to the additional_bindings of the http rule.
+//
+// It also checks that methods with a google.api.http binding set
+// google.api.method_signature, listing the convenient arguments for clients.
+//
+// Optionally, it checks that each service defines at least one method with
+// permissions, unless the service is internal. There's no service option to
+// mark a whole service as internal, so a service is considered internal when
+// all of its methods are internal only (qdrant.cloud.common.v1.internal_only).
+// Disabled by default, the rule also needs the require_permissioned_service
+// option.
+//
+// Optionally, it reports rpc methods requiring all of their permissions on
+// several resources (e.g: [read:cluster write:backup]), as AND permissions
+// usually target a single resource.
+//
+// It also checks that each permission is consistently required under either
+// AND or OR logic (requires_all_permissions) by the methods of a service.
+//
+// Optionally, it checks that the methods of a service use the same
+// account_id_expression shape, referencing the account id from the same root
+// (e.g: all resource.account_id). Disabled by default, the rule also needs the
+// consistent_account_id_expression option.
+//
+// Optionally, it reports sets of AND permissions (e.g: [read:cluster write:cluster])
+// which are repeated by many methods of a file, and should be extracted into a
+// role. The threshold is configurable with the role_threshold option.
+// The default value is: 5
+//
+// Optionally, it checks that the methods of each service are declared in CRUD
+// order of their name prefixes. The order is configurable with the
+// method_order option.
+// The default value is: List, Get, Create, Update, Delete
+//
+// It also checks that methods exempted from the permissions breaking checks
+// (qdrant.cloud.common.v1.permissions_breaking_exempt) justify it with a leading
+// "// Exempt because:" comment.
+//
+// The profile option selects a bundle of option defaults, which the other
+// options override:
+// - strict: enables all of the optional checks toggled by a bool option:
+// require_permissioned_service, check_streaming_response_body and
+// consistent_account_id_expression. Their rules still need to be enabled.
+// - lenient: keeps all of the optional checks disabled.
+//
+// To use this plugin:
+//
+//	# buf.yaml
+//	version: v2
+//	lint:
+//	  use:
+//	   - STANDARD # omit if you do not want to use the rules builtin to buf
+//	   - QDRANT_CLOUD_METHOD_OPTIONS
+//	   - QDRANT_CLOUD_INTERNAL_STREAMING_PERMISSIONS # optional
+//	   - QDRANT_CLOUD_METHOD_MESSAGE_PACKAGE
+//	   - QDRANT_CLOUD_ACCOUNT_ID_EXPRESSION_TYPES # optional
+//	   - QDRANT_CLOUD_PERMISSION_VERBS
+//	   - QDRANT_CLOUD_PERMISSION_METHOD_PREFIX
+//	   - QDRANT_CLOUD_PERMISSION_VERB_PAIRS # optional
+//	   - QDRANT_CLOUD_PERMISSION_RESOURCES # optional
+//	   - QDRANT_CLOUD_SORTED_PERMISSIONS
+//	   - QDRANT_CLOUD_KNOWN_PERMISSIONS
+//	   - QDRANT_CLOUD_HTTP_PATH_FIELDS
+//	   - QDRANT_CLOUD_HTTP_PATH_DUPLICATE_FIELDS
+//	   - QDRANT_CLOUD_HTTP_PATH_VERSION
+//	   - QDRANT_CLOUD_HTTP_STREAMING_RESPONSE_BODY # optional
+//	   - QDRANT_CLOUD_MUTATING_METHOD_GET
+//	   - QDRANT_CLOUD_PERMISSION_ROLES # optional
+//	   - QDRANT_CLOUD_PERMISSION_LOGIC_CONSISTENCY
+//	   - QDRANT_CLOUD_ACCOUNT_ID_EXPRESSION_CONSISTENCY # optional
+//	   - QDRANT_CLOUD_PERMISSIONED_SERVICE # optional
+//	   - QDRANT_CLOUD_BREAKING_EXEMPT_JUSTIFICATION
+//	   - QDRANT_CLOUD_METHOD_ORDER # optional
+//	   - QDRANT_CLOUD_HTTP_METHOD_SIGNATURE
+//	plugins:
+//	  - plugin: buf-plugin-method-options
+//	    # Uncomment in case you need to configure the list of method options to validate.
+//	    # options:
+//	    #  required_method_options:
+//	    #    - "qdrant.cloud.common.v1.permissions"
+//	    #  # Extensions resolved by their full name from the proto registry,
+//	    #  # which can be used in required_method_options.
+//	    #  register_extensions:
+//	    #    - "qdrant.cloud.common.v1.requires_authentication"
+//	    #  # Method name prefixes which must set a non-empty account_id_expression,
+//	    #  # even if they don't have permissions.
+//	    #  require_account_id_expression_prefixes:
+//	    #    - "ListMetrics"
+//	    #  # Require each service to define at least one method with permissions.
+//	    #  require_permissioned_service: true
+//	    #  # Select a bundle of option defaults: strict or lenient.
+//	    #  profile: strict
+package methodoptions
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checkutil"
+	"buf.build/go/bufplugin/descriptor"
+	"buf.build/go/bufplugin/info"
+	"buf.build/go/bufplugin/option"
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	celtypes "github.com/google/cel-go/common/types"
+	googleann "google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	commonv1 "github.com/qdrant/qdrant-cloud-public-api/gen/go/qdrant/cloud/common/v1"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+const (
+	// methodOptionsRuleID is the Rule ID of the methodOptions rule.
+	methodOptionsRuleID = "QDRANT_CLOUD_METHOD_OPTIONS"
+	// methodOptionsOptionKey is the option key to override the default list of required options.
+	methodOptionsOptionKey = "required_method_options"
+	// registerExtensionsOptionKey is the option key to register additional extensions, resolved by
+	// their full name from the proto registry, which can be used as required options.
+	registerExtensionsOptionKey = "register_extensions"
+	// requireAccountIdExpressionPrefixesOptionKey is the option key to set the method name prefixes
+	// (e.g: ListMetrics) which must set a non-empty account_id_expression, regardless of permissions.
+	requireAccountIdExpressionPrefixesOptionKey = "require_account_id_expression_prefixes"
+	// internalStreamingPermissionsRuleID is the Rule ID of the internalStreamingPermissions rule.
+	internalStreamingPermissionsRuleID = "QDRANT_CLOUD_INTERNAL_STREAMING_PERMISSIONS"
+	// methodMessagePackageRuleID is the Rule ID of the methodMessagePackage rule.
+	methodMessagePackageRuleID = "QDRANT_CLOUD_METHOD_MESSAGE_PACKAGE"
+	// methodMessagePackageAllowlistOptionKey is the option key to override the default list of
+	// packages which method input/output messages can belong to, besides the service package.
+	methodMessagePackageAllowlistOptionKey = "method_message_package_allowlist"
+	// permissionVerbsRuleID is the Rule ID of the permissionVerbs rule.
+	permissionVerbsRuleID = "QDRANT_CLOUD_PERMISSION_VERBS"
+	// permissionVerbsOptionKey is the option key to override the default list of allowed permission verbs.
+	permissionVerbsOptionKey = "permission_verbs"
+	// permissionMethodPrefixRuleID is the Rule ID of the permissionMethodPrefix rule.
+	permissionMethodPrefixRuleID = "QDRANT_CLOUD_PERMISSION_METHOD_PREFIX"
+	// permissionVerbPrefixesOptionKey is the option key to override the default method name prefixes
+	// required for the permission verbs, with the format "verb=Prefix1,Prefix2".
+	permissionVerbPrefixesOptionKey = "permission_verb_prefixes"
+	// sortedPermissionsRuleID is the Rule ID of the sortedPermissions rule.
+	sortedPermissionsRuleID = "QDRANT_CLOUD_SORTED_PERMISSIONS"
+	// knownPermissionsRuleID is the Rule ID of the knownPermissions rule.
+	knownPermissionsRuleID = "QDRANT_CLOUD_KNOWN_PERMISSIONS"
+	// knownPermissionsOptionKey is the option key to set the allowlist of known permissions.
+	knownPermissionsOptionKey = "known_permissions"
+	// maxPermissionSuggestionDistance is the max edit distance of a known permission to
+	// be suggested for an unknown one.
+	maxPermissionSuggestionDistance = 2
+	// httpPathFieldsRuleID is the Rule ID of the httpPathFields rule.
+	httpPathFieldsRuleID = "QDRANT_CLOUD_HTTP_PATH_FIELDS"
+	// httpPathDuplicateFieldsRuleID is the Rule ID of the httpPathDuplicateFields rule.
+	httpPathDuplicateFieldsRuleID = "QDRANT_CLOUD_HTTP_PATH_DUPLICATE_FIELDS"
+	// httpPathVersionRuleID is the Rule ID of the httpPathVersion rule.
+	httpPathVersionRuleID = "QDRANT_CLOUD_HTTP_PATH_VERSION"
+	// httpStreamingResponseBodyRuleID is the Rule ID of the httpStreamingResponseBody rule.
+	httpStreamingResponseBodyRuleID = "QDRANT_CLOUD_HTTP_STREAMING_RESPONSE_BODY"
+	// checkStreamingResponseBodyOptionKey is the option key to enable the httpStreamingResponseBody rule.
+	checkStreamingResponseBodyOptionKey = "check_streaming_response_body"
+	// mutatingMethodGetRuleID is the Rule ID of the mutatingMethodGet rule.
+	mutatingMethodGetRuleID = "QDRANT_CLOUD_MUTATING_METHOD_GET"
+	// permissionRolesRuleID is the Rule ID of the permissionRoles rule.
+	permissionRolesRuleID = "QDRANT_CLOUD_PERMISSION_ROLES"
+	// roleThresholdOptionKey is the option key to override the default number of methods using
+	// the same permission set from which the set should be extracted into a role.
+	roleThresholdOptionKey = "role_threshold"
+	// permissionedServiceRuleID is the Rule ID of the permissionedService rule.
+	permissionedServiceRuleID = "QDRANT_CLOUD_PERMISSIONED_SERVICE"
+	// requirePermissionedServiceOptionKey is the option key to enable the permissionedService rule.
+	requirePermissionedServiceOptionKey = "require_permissioned_service"
+	// internalOnlyExtensionName is the full name of the method option marking a method as internal only.
+	// It's resolved from the linted files, as it isn't part of the Go dependencies.
+	internalOnlyExtensionName = "qdrant.cloud.common.v1.internal_only"
+	// breakingExemptJustificationRuleID is the Rule ID of the breakingExemptJustification rule.
+	breakingExemptJustificationRuleID = "QDRANT_CLOUD_BREAKING_EXEMPT_JUSTIFICATION"
+	// methodOrderRuleID is the Rule ID of the methodOrder rule.
+	methodOrderRuleID = "QDRANT_CLOUD_METHOD_ORDER"
+	// methodOrderOptionKey is the option key to override the default canonical order of the
+	// method name prefixes within a service.
+	methodOrderOptionKey = "method_order"
+	// httpMethodSignatureRuleID is the Rule ID of the httpMethodSignature rule.
+	httpMethodSignatureRuleID = "QDRANT_CLOUD_HTTP_METHOD_SIGNATURE"
+	// permissionVerbPairsRuleID is the Rule ID of the permissionVerbPairs rule.
+	permissionVerbPairsRuleID = "QDRANT_CLOUD_PERMISSION_VERB_PAIRS"
+	// permissionVerbPairsOptionKey is the option key to override the default verbs implied by
+	// a permission verb on the same resource, with the format "verb=impliedVerb1,impliedVerb2".
+	permissionVerbPairsOptionKey = "permission_verb_pairs"
+	// permissionResourcesRuleID is the Rule ID of the permissionResources rule.
+	permissionResourcesRuleID = "QDRANT_CLOUD_PERMISSION_RESOURCES"
+	// permissionLogicConsistencyRuleID is the Rule ID of the permissionLogicConsistency rule.
+	permissionLogicConsistencyRuleID = "QDRANT_CLOUD_PERMISSION_LOGIC_CONSISTENCY"
+	// accountIdExpressionConsistencyRuleID is the Rule ID of the accountIdExpressionConsistency rule.
+	accountIdExpressionConsistencyRuleID = "QDRANT_CLOUD_ACCOUNT_ID_EXPRESSION_CONSISTENCY"
+	// consistentAccountIdExpressionOptionKey is the option key to enable the accountIdExpressionConsistency rule.
+	consistentAccountIdExpressionOptionKey = "consistent_account_id_expression"
+	// accountIdExpressionTypesRuleID is the Rule ID of the accountIdExpressionTypes rule.
+	accountIdExpressionTypesRuleID = "QDRANT_CLOUD_ACCOUNT_ID_EXPRESSION_TYPES"
+	// methodOptionsFieldNumber is the field number of the options in google.protobuf.MethodDescriptorProto.
+	methodOptionsFieldNumber = 4
+)
+
+var (
+	methodOptionsRuleSpec = &check.RuleSpec{
+		ID:      methodOptionsRuleID,
+		Default: true,
+		Purpose: `Checks that all rpc methods define a set of required options.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkMethodOptions, checkutil.WithoutImports()),
+	}
+	internalStreamingPermissionsRuleSpec = &check.RuleSpec{
+		ID:      internalStreamingPermissionsRuleID,
+		Default: false,
+		Purpose: `Checks that internal only streaming rpc methods don't set permissions.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkInternalStreamingPermissions, checkutil.WithoutImports()),
+	}
+	methodMessagePackageRuleSpec = &check.RuleSpec{
+		ID:      methodMessagePackageRuleID,
+		Default: true,
+		Purpose: `Checks that all rpc methods input and output messages are defined in the same package as the service.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkMethodMessagePackage, checkutil.WithoutImports()),
+	}
+	permissionVerbsRuleSpec = &check.RuleSpec{
+		ID:      permissionVerbsRuleID,
+		Default: true,
+		Purpose: `Checks that all rpc methods permissions use a known verb (e.g: read:cluster).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkPermissionVerbs, checkutil.WithoutImports()),
+	}
+	permissionMethodPrefixRuleSpec = &check.RuleSpec{
+		ID:      permissionMethodPrefixRuleID,
+		Default: true,
+		Purpose: `Checks that rpc methods with a permission verb (e.g: delete:cluster) are named with the matching prefix (e.g: DeleteCluster).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkPermissionMethodPrefix, checkutil.WithoutImports()),
+	}
+	sortedPermissionsRuleSpec = &check.RuleSpec{
+		ID:      sortedPermissionsRuleID,
+		Default: true,
+		Purpose: `Checks that the permissions of all rpc methods are declared in sorted order.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkSortedPermissions, checkutil.WithoutImports()),
+	}
+	knownPermissionsRuleSpec = &check.RuleSpec{
+		ID:      knownPermissionsRuleID,
+		Default: true,
+		Purpose: `Checks that all rpc methods permissions are in the known_permissions allowlist, if set.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkKnownPermissions, checkutil.WithoutImports()),
+	}
+	httpPathFieldsRuleSpec = &check.RuleSpec{
+		ID:      httpPathFieldsRuleID,
+		Default: true,
+		Purpose: `Checks that all rpc methods http path templates reference existing input fields.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkHTTPPathFields, checkutil.WithoutImports()),
+	}
+	httpPathDuplicateFieldsRuleSpec = &check.RuleSpec{
+		ID:      httpPathDuplicateFieldsRuleID,
+		Default: true,
+		Purpose: `Checks that the fields bound to rpc methods http path templates aren't duplicated by the embedded messages of the input.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkHTTPPathDuplicateFields, checkutil.WithoutImports()),
+	}
+	httpPathVersionRuleSpec = &check.RuleSpec{
+		ID:      httpPathVersionRuleID,
+		Default: true,
+		Purpose: `Checks that the version segment of rpc methods http paths (e.g: /v1/) matches the version of the proto package.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkHTTPPathVersion, checkutil.WithoutImports()),
+	}
+	httpStreamingResponseBodyRuleSpec = &check.RuleSpec{
+		ID:      httpStreamingResponseBodyRuleID,
+		Default: false,
+		Purpose: `Checks that server streaming rpc methods with a google.api.http binding set its response_body, if enabled with the check_streaming_response_body option.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkHTTPStreamingResponseBody, checkutil.WithoutImports()),
+	}
+	mutatingMethodGetRuleSpec = &check.RuleSpec{
+		ID:      mutatingMethodGetRuleID,
+		Default: true,
+		Purpose: `Checks that rpc methods with side effects (e.g: DeleteCluster) aren't bound to http GET.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkMutatingMethodGet, checkutil.WithoutImports()),
+	}
+	permissionRolesRuleSpec = &check.RuleSpec{
+		ID:      permissionRolesRuleID,
+		Default: false,
+		Purpose: `Checks that sets of AND permissions repeated by many rpc methods are extracted into a role.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkPermissionRoles, checkutil.WithoutImports()),
+	}
+	permissionedServiceRuleSpec = &check.RuleSpec{
+		ID:      permissionedServiceRuleID,
+		Default: false,
+		Purpose: `Checks that each service defines at least one rpc method with permissions, if enabled with the require_permissioned_service option.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewServiceRuleHandler(checkPermissionedService, checkutil.WithoutImports()),
+	}
+	breakingExemptJustificationRuleSpec = &check.RuleSpec{
+		ID:      breakingExemptJustificationRuleID,
+		Default: true,
+		Purpose: `Checks that rpc methods exempted from the permissions breaking checks justify it with a comment.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkBreakingExemptJustification, checkutil.WithoutImports()),
+	}
+	methodOrderRuleSpec = &check.RuleSpec{
+		ID:      methodOrderRuleID,
+		Default: false,
+		Purpose: `Checks that the rpc methods of each service are declared in CRUD order (e.g: List, Get, Create, Update, Delete).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewServiceRuleHandler(checkMethodOrder, checkutil.WithoutImports()),
+	}
+	httpMethodSignatureRuleSpec = &check.RuleSpec{
+		ID:      httpMethodSignatureRuleID,
+		Default: true,
+		Purpose: `Checks that rpc methods with a google.api.http binding also set google.api.method_signature.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkHTTPMethodSignature, checkutil.WithoutImports()),
+	}
+	permissionVerbPairsRuleSpec = &check.RuleSpec{
+		ID:      permissionVerbPairsRuleID,
+		Default: false,
+		Purpose: `Checks that rpc methods requiring all of their permissions pair a verb (e.g: write:cluster) with the verbs it implies (e.g: read:cluster).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkPermissionVerbPairs, checkutil.WithoutImports()),
+	}
+	permissionResourcesRuleSpec = &check.RuleSpec{
+		ID:      permissionResourcesRuleID,
+		Default: false,
+		Purpose: `Checks that rpc methods requiring all of their permissions target a single resource (e.g: read:cluster and write:cluster).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkPermissionResources, checkutil.WithoutImports()),
+	}
+	permissionLogicConsistencyRuleSpec = &check.RuleSpec{
+		ID:      permissionLogicConsistencyRuleID,
+		Default: true,
+		Purpose: `Checks that each permission is consistently used under either AND or OR logic by the rpc methods of a service.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkPermissionLogicConsistency, checkutil.WithoutImports()),
+	}
+	accountIdExpressionConsistencyRuleSpec = &check.RuleSpec{
+		ID:      accountIdExpressionConsistencyRuleID,
+		Default: false,
+		Purpose: `Checks that the rpc methods of a service use the same account_id_expression shape, if enabled with the consistent_account_id_expression option.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkAccountIdExpressionConsistency, checkutil.WithoutImports()),
+	}
+	accountIdExpressionTypesRuleSpec = &check.RuleSpec{
+		ID:      accountIdExpressionTypesRuleID,
+		Default: false,
+		Purpose: `Checks that the account_id_expression of rpc methods type-checks as a CEL expression against the method input and output messages.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkAccountIdExpressionTypes, checkutil.WithoutImports()),
+	}
+	profiles = pluginutil.Profiles{
+		"strict": {
+			requirePermissionedServiceOptionKey:    true,
+			checkStreamingResponseBodyOptionKey:    true,
+			consistentAccountIdExpressionOptionKey: true,
+		},
+		"lenient": {},
+	}
+	// optionKeys are the plugin-specific option keys recognized by the plugin.
+	optionKeys = []string{
+		pluginutil.ProfileOptionKey,
+		methodOptionsOptionKey,
+		registerExtensionsOptionKey,
+		requireAccountIdExpressionPrefixesOptionKey,
+		methodMessagePackageAllowlistOptionKey,
+		permissionVerbsOptionKey,
+		permissionVerbPrefixesOptionKey,
+		knownPermissionsOptionKey,
+		roleThresholdOptionKey,
+		requirePermissionedServiceOptionKey,
+		methodOrderOptionKey,
+		permissionVerbPairsOptionKey,
+		checkStreamingResponseBodyOptionKey,
+		consistentAccountIdExpressionOptionKey,
+	}
+	// Spec is the check spec of the plugin, served by its command and run
+	// in-process by the analysis package.
+	Spec = pluginutil.WithDisableRulesEnv(pluginutil.WithRuleMetadata(pluginutil.WithStrictOptions(pluginutil.WithProfiles(&check.Spec{
+		Rules: []*check.RuleSpec{
+			methodOptionsRuleSpec,
+			internalStreamingPermissionsRuleSpec,
+			methodMessagePackageRuleSpec,
+			accountIdExpressionTypesRuleSpec,
+			permissionVerbsRuleSpec,
+			permissionMethodPrefixRuleSpec,
+			sortedPermissionsRuleSpec,
+			knownPermissionsRuleSpec,
+			httpPathFieldsRuleSpec,
+			httpPathDuplicateFieldsRuleSpec,
+			httpPathVersionRuleSpec,
+			httpStreamingResponseBodyRuleSpec,
+			mutatingMethodGetRuleSpec,
+			permissionRolesRuleSpec,
+			permissionedServiceRuleSpec,
+			breakingExemptJustificationRuleSpec,
+			methodOrderRuleSpec,
+			httpMethodSignatureRuleSpec,
+			permissionVerbPairsRuleSpec,
+			permissionResourcesRuleSpec,
+			permissionLogicConsistencyRuleSpec,
+			accountIdExpressionConsistencyRuleSpec,
+		},
+		Info: &info.Spec{
+			Documentation: `A plugin that checks that all rpc methods define a set of required options.`,
+			SPDXLicenseID: "",
+			LicenseURL:    "",
+		},
+	}, profiles), optionKeys...), optionKeys...))
+	permissionsOption            = commonv1.E_Permissions
+	restHTTPOption               = googleann.E_Http
+	methodSignatureOption        = googleann.E_MethodSignature
+	requiresAuthenticationOption = commonv1.E_RequiresAuthentication
+	accountIdExpressionOption    = commonv1.E_AccountIdExpression
+	requiresAllPermissionsOption = commonv1.E_RequiresAllPermissions
+
+	extensionRegistry = map[string]protoreflect.ExtensionType{
+		string(permissionsOption.TypeDescriptor().Descriptor().FullName()):     permissionsOption,
+		string(restHTTPOption.TypeDescriptor().Descriptor().FullName()):        restHTTPOption,
+		string(methodSignatureOption.TypeDescriptor().Descriptor().FullName()): methodSignatureOption,
+	}
+	requiredMethodOptionExtensions = []string{
+		string(permissionsOption.TypeDescriptor().Descriptor().FullName()),
+		string(restHTTPOption.TypeDescriptor().Descriptor().FullName()),
+	}
+	defaultPermissionVerbs = []string{"read", "write", "manage", "delete", "create"}
+	defaultRoleThreshold   = int64(5)
+	// methods with permissions using these verbs must be named with one of the prefixes.
+	defaultPermissionVerbPrefixes = map[string][]string{
+		"delete": {"Delete"},
+	}
+	// methods requiring all of their permissions with these verbs must also require the paired
+	// verbs on the same resource.
+	defaultPermissionVerbPairs = map[string][]string{
+		"write": {"read"},
+	}
+	// well-known types can be used as input/output of any method.
+	defaultMethodMessagePackageAllowlist = []string{
+		"google.protobuf",
+	}
+	// fieldPathRegexp matches dot-separated field paths (e.g: cluster.account_id).
+	fieldPathRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	// versionRegexp matches the version of a package or path segment (e.g: v1 or v2beta1).
+	versionRegexp = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]*)?$`)
+	// methods of a service must be declared in this order of prefixes.
+	defaultMethodOrder = []string{"List", "Get", "Create", "Update", "Delete"}
+	// methods with these prefixes only read resources.
+	readMethodPrefixes = []string{"Get", "List"}
+	// methods with these prefixes have side effects.
+	mutatingMethodPrefixes = []string{"Create", "Update", "Delete"}
+)
+
+func checkMethodOptions(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	requiredOptions := requiredMethodOptionExtensions
+	optionValue, err := option.GetStringSliceValue(request.Options(), methodOptionsOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(optionValue) > 0 {
+		requiredOptions = optionValue
+	}
+
+	registry, err := getExtensionRegistry(request, responseWriter)
+	if err != nil {
+		return err
+	}
+
+	options := methodDescriptor.Options()
+	internalOnly, err := pluginutil.GetBoolExtension(request, options, internalOnlyExtensionName)
+	if err != nil {
+		return err
+	}
+
+	for _, extensionKey := range requiredOptions {
+		extension, found := registry[extensionKey]
+		if !found {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("extension key %q does not exist", extensionKey),
+			)
+			return nil
+		}
+		if !proto.HasExtension(options, extension) {
+			// special case for "qdrant.cloud.common.v1.permissions": in case
+			// there is "qdrant.cloud.common.v1.requires_authentication" set to
+			// false, setting permissions isn't needed.
+			if extensionKey == "qdrant.cloud.common.v1.permissions" && proto.HasExtension(options, requiresAuthenticationOption) {
+				val := proto.GetExtension(options, requiresAuthenticationOption).(bool)
+				if !val {
+					// requires_authentication is false, we skip it.
+					break
+				}
+			}
+			// internal only methods aren't exposed through a REST endpoint.
+			if extensionKey == "google.api.http" && internalOnly {
+				continue
+			}
+			responseWriter.AddAnnotation(
+				check.WithMessagef("Method %q does not define the %q option", methodDescriptor.FullName(), extension.TypeDescriptor().FullName()),
+				check.WithDescriptor(methodDescriptor),
+			)
+		}
+	}
+
+	// Check for permissions + account_id_expression conflict
+	if proto.HasExtension(options, permissionsOption) && proto.HasExtension(options, accountIdExpressionOption) {
+		permissions := getPermissions(options)
+		accountIdExpression := proto.GetExtension(options, accountIdExpressionOption).(string)
+
+		// If there are permissions but account_id_expression is empty,
+		// this is invalid because permissions are checked in the scope of the account
+		if len(permissions) > 0 && accountIdExpression == "" {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("Method %q has permissions set but account_id_expression is empty. Methods with permissions require a non-empty account_id_expression since permissions are checked in the scope of the account", methodDescriptor.FullName()),
+				withOptionLocation(methodDescriptor, accountIdExpressionOption),
+			)
+		}
+	}
+
+	// Check for internal_only + google.api.http conflict
+	if internalOnly && proto.HasExtension(options, restHTTPOption) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q is internal_only but declares an http binding", methodDescriptor.FullName()),
+			withOptionLocation(methodDescriptor, restHTTPOption),
+		)
+	}
+
+	// account_id_expression must reference fields of the input message (e.g:
+	// cluster.account_id), a string or int literal would scope the method to one
+	// account for everyone.
+	if proto.HasExtension(options, accountIdExpressionOption) {
+		accountIdExpression := strings.TrimSpace(proto.GetExtension(options, accountIdExpressionOption).(string))
+		hasLiteral, err := hasCELLiteral(accountIdExpression)
+		if err != nil {
+			return err
+		}
+		if hasLiteral {
+			responseWriter.AddAnnotation(
+				check.WithMessage("account_id_expression appears to hardcode an account id"),
+				withOptionLocation(methodDescriptor, accountIdExpressionOption),
+			)
+		} else if strings.HasPrefix(accountIdExpression, "response.") && isReadMethod(methodDescriptor) {
+			// Read methods are authorized before the response exists.
+			responseWriter.AddAnnotation(
+				check.WithMessagef("account_id_expression on %s references response.* which is evaluated pre-response", methodDescriptor.Name()),
+				withOptionLocation(methodDescriptor, accountIdExpressionOption),
+			)
+		} else if fieldPathRegexp.MatchString(accountIdExpression) {
+			// The field path can be prefixed with request (e.g: request.account_id),
+			// unless the input message has a request field itself.
+			fieldPath := accountIdExpression
+			if methodDescriptor.Input().Fields().ByName("request") == nil {
+				fieldPath = strings.TrimPrefix(fieldPath, "request.")
+			}
+			// Missing fields aren't reported, as the expression can be
+			// evaluated against a request wrapping the input message.
+			field := getFieldPath(methodDescriptor.Input(), fieldPath)
+			if field != nil && (field.Kind() != protoreflect.StringKind || field.IsList()) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("account_id_expression references %s but the field is %s", accountIdExpression, fieldKindName(field)),
+					withOptionLocation(methodDescriptor, accountIdExpressionOption),
+				)
+			}
+		}
+	}
+
+	// Some account-scoped methods don't have permissions, but still need the
+	// account scoping.
+	accountIdExpressionPrefixes, err := option.GetStringSliceValue(request.Options(), requireAccountIdExpressionPrefixesOptionKey)
+	if err != nil {
+		return err
+	}
+	methodName := string(methodDescriptor.Name())
+	if slices.ContainsFunc(accountIdExpressionPrefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+		if proto.GetExtension(options, accountIdExpressionOption).(string) == "" {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("Method %q must set account_id_expression", methodName),
+				check.WithDescriptor(methodDescriptor),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkInternalStreamingPermissions reports internal only streaming methods
+// (e.g: telemetry) which set permissions, as they're usually meaningless for
+// them and suggest a copy-paste from another method.
+func checkInternalStreamingPermissions(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	if !methodDescriptor.IsStreamingServer() && !methodDescriptor.IsStreamingClient() {
+		return nil
+	}
+	options := methodDescriptor.Options()
+	internalOnly, err := pluginutil.GetBoolExtension(request, options, internalOnlyExtensionName)
+	if err != nil {
+		return err
+	}
+	if internalOnly && proto.HasExtension(options, permissionsOption) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("internal streaming method %q sets permissions unnecessarily", methodDescriptor.Name()),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// getExtensionRegistry returns the known extensions, including the ones
+// registered with the register_extensions option. Extensions which can't be
+// resolved from the proto registry are reported.
+func getExtensionRegistry(request check.Request, responseWriter check.ResponseWriter) (map[string]protoreflect.ExtensionType, error) {
+	extensionNames, err := option.GetStringSliceValue(request.Options(), registerExtensionsOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	registry := maps.Clone(extensionRegistry)
+	for _, extensionName := range extensionNames {
+		extension, err := protoregistry.GlobalTypes.FindExtensionByName(protoreflect.FullName(extensionName))
+		if err != nil {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("extension %q set in the %s option can't be resolved from the proto registry", extensionName, registerExtensionsOptionKey),
+			)
+			continue
+		}
+		registry[extensionName] = extension
+	}
+	return registry, nil
+}
+
+func checkMethodMessagePackage(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	allowedPackages := defaultMethodMessagePackageAllowlist
+	optionValue, err := option.GetStringSliceValue(request.Options(), methodMessagePackageAllowlistOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(optionValue) > 0 {
+		allowedPackages = optionValue
+	}
+
+	servicePackage := methodDescriptor.ParentFile().Package()
+	messages := []struct {
+		kind       string
+		descriptor protoreflect.MessageDescriptor
+	}{
+		{kind: "input", descriptor: methodDescriptor.Input()},
+		{kind: "output", descriptor: methodDescriptor.Output()},
+	}
+	for _, message := range messages {
+		messagePackage := message.descriptor.ParentFile().Package()
+		if messagePackage == servicePackage || isPackageAllowed(messagePackage, allowedPackages) {
+			continue
+		}
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q %s is in package %q not the service package %q", methodDescriptor.FullName(), message.kind, messagePackage, servicePackage),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// isPackageAllowed checks if the given package, or any of its parent packages,
+// is part of the allowed packages.
+func isPackageAllowed(pkg protoreflect.FullName, allowedPackages []string) bool {
+	for _, allowedPackage := range allowedPackages {
+		if string(pkg) == allowedPackage || strings.HasPrefix(string(pkg), allowedPackage+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkPermissionVerbs(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	allowedVerbs := defaultPermissionVerbs
+	optionValue, err := option.GetStringSliceValue(request.Options(), permissionVerbsOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(optionValue) > 0 {
+		allowedVerbs = optionValue
+	}
+
+	for _, perm := range getPermissions(methodDescriptor.Options()) {
+		verb, _, _ := strings.Cut(perm, ":")
+		if !slices.Contains(allowedVerbs, verb) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("permission %q uses unknown verb %q", perm, verb),
+				check.WithDescriptor(methodDescriptor),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkPermissionMethodPrefix checks that rpc methods with a permission verb
+// mapped to method name prefixes (e.g: delete -> Delete) are named with one of
+// them, as a mismatch (e.g: delete:book on UpdateBook) is probably a mistake.
+func checkPermissionMethodPrefix(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	verbPrefixes, err := getPermissionVerbPrefixes(request)
+	if err != nil {
+		return err
+	}
+	methodName := string(methodDescriptor.Name())
+	var reportedVerbs []string
+	for _, perm := range getPermissions(methodDescriptor.Options()) {
+		verb, _, _ := strings.Cut(perm, ":")
+		prefixes, found := verbPrefixes[verb]
+		if !found || slices.Contains(reportedVerbs, verb) {
+			continue
+		}
+		if slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+			continue
+		}
+		reportedVerbs = append(reportedVerbs, verb)
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q has a %s permission but isn't a %s method", methodName, verb, strings.Join(prefixes, "/")),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// getPermissionVerbPrefixes returns the method name prefixes required for each
+// permission verb, configured as a plugin option with the format
+// "verb=Prefix1,Prefix2" (e.g: delete=Delete,Purge), or the default ones.
+func getPermissionVerbPrefixes(request check.Request) (map[string][]string, error) {
+	optionValue, err := option.GetStringSliceValue(request.Options(), permissionVerbPrefixesOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(optionValue) == 0 {
+		return defaultPermissionVerbPrefixes, nil
+	}
+	verbPrefixes := make(map[string][]string)
+	for _, value := range optionValue {
+		verb, prefixes, found := strings.Cut(value, "=")
+		if !found || verb == "" || prefixes == "" {
+			return nil, fmt.Errorf("invalid %s option value %q, expected format: verb=Prefix1,Prefix2", permissionVerbPrefixesOptionKey, value)
+		}
+		verbPrefixes[verb] = append(verbPrefixes[verb], strings.Split(prefixes, ",")...)
+	}
+	return verbPrefixes, nil
+}
+
+// checkPermissionVerbPairs checks that methods requiring all of their
+// permissions (AND logic) pair a verb with the verbs it implies on the same
+// resource (e.g: write:cluster with read:cluster, as what can't be read can't
+// be written).
+func checkPermissionVerbPairs(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	verbPairs, err := getPermissionVerbPairs(request)
+	if err != nil {
+		return err
+	}
+	options := methodDescriptor.Options()
+	if proto.HasExtension(options, requiresAllPermissionsOption) && !proto.GetExtension(options, requiresAllPermissionsOption).(bool) {
+		return nil
+	}
+	permissions := getPermissions(options)
+	for _, perm := range permissions {
+		verb, resource, found := strings.Cut(perm, ":")
+		if !found {
+			continue
+		}
+		for _, impliedVerb := range verbPairs[verb] {
+			impliedPerm := impliedVerb + ":" + resource
+			if !slices.Contains(permissions, impliedPerm) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("Method %q requires %s but not %s", methodDescriptor.Name(), perm, impliedPerm),
+					check.WithDescriptor(methodDescriptor),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPermissionResources reports methods requiring all of their permissions
+// (AND logic) on several resources (e.g: read:cluster and write:backup), which
+// is often a mistake. The intent has to be verified, as some methods do span
+// resources (e.g: restoring a backup into a cluster).
+func checkPermissionResources(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if proto.HasExtension(options, requiresAllPermissionsOption) && !proto.GetExtension(options, requiresAllPermissionsOption).(bool) {
+		return nil
+	}
+	var resources []string
+	for _, perm := range getPermissions(options) {
+		_, resource, found := strings.Cut(perm, ":")
+		if found && !slices.Contains(resources, resource) {
+			resources = append(resources, resource)
+		}
+	}
+	if len(resources) > 1 {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q requires AND permissions across resources %v; verify intent", methodDescriptor.Name(), resources),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// getPermissionVerbPairs returns the verbs implied by each permission verb,
+// either from the permission_verb_pairs option or the default ones.
+func getPermissionVerbPairs(request check.Request) (map[string][]string, error) {
+	optionValue, err := option.GetStringSliceValue(request.Options(), permissionVerbPairsOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(optionValue) == 0 {
+		return defaultPermissionVerbPairs, nil
+	}
+	verbPairs := make(map[string][]string)
+	for _, value := range optionValue {
+		verb, impliedVerbs, found := strings.Cut(value, "=")
+		if !found || verb == "" || impliedVerbs == "" {
+			return nil, fmt.Errorf("invalid %s option value %q, expected format: verb=impliedVerb1,impliedVerb2", permissionVerbPairsOptionKey, value)
+		}
+		verbPairs[verb] = append(verbPairs[verb], strings.Split(impliedVerbs, ",")...)
+	}
+	return verbPairs, nil
+}
+
+// checkSortedPermissions checks that the permissions of the method are declared
+// in sorted order, so diffs are readable and comparisons stable.
+func checkSortedPermissions(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	if !slices.IsSorted(getPermissions(methodDescriptor.Options())) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("permissions on %q are not sorted", methodDescriptor.Name()),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+func checkKnownPermissions(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	knownPermissions, err := option.GetStringSliceValue(request.Options(), knownPermissionsOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(knownPermissions) == 0 {
+		return nil
+	}
+
+	for _, perm := range getPermissions(methodDescriptor.Options()) {
+		if slices.Contains(knownPermissions, perm) {
+			continue
+		}
+		if suggestion := suggestPermission(perm, knownPermissions); suggestion != "" {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("unknown permission %q, did you mean %q?", perm, suggestion),
+				check.WithDescriptor(methodDescriptor),
+			)
+		} else {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("unknown permission %q", perm),
+				check.WithDescriptor(methodDescriptor),
+			)
+		}
+	}
+
+	return nil
+}
+
+// suggestPermission returns the known permission closest to the given one,
+// if it's within the max suggestion distance (e.g: a typo), or an empty string.
+func suggestPermission(permission string, knownPermissions []string) string {
+	suggestion := ""
+	suggestionDistance := maxPermissionSuggestionDistance + 1
+	for _, knownPermission := range knownPermissions {
+		if distance := levenshteinDistance(permission, knownPermission); distance < suggestionDistance {
+			suggestion = knownPermission
+			suggestionDistance = distance
+		}
+	}
+	return suggestion
+}
+
+// levenshteinDistance returns the minimum number of single character edits
+// (insertions, deletions or substitutions) to change a into b.
+func levenshteinDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
+
+func checkHTTPPathFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if !proto.HasExtension(options, restHTTPOption) {
+		return nil
+	}
+	httpRule := proto.GetExtension(options, restHTTPOption).(*googleann.HttpRule)
+
+	for i, binding := range getHTTPRuleBindings(httpRule) {
+		for _, variable := range getPathTemplateVariables(getHTTPRulePath(binding)) {
+			if !hasFieldPath(methodDescriptor.Input(), variable) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("http %s references {%s} but input has no such field", httpBindingPathName(i), variable),
+					withOptionLocation(methodDescriptor, restHTTPOption),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkHTTPPathDuplicateFields checks that the input fields bound to the http
+// path (e.g: {book_id}) aren't also set by a message field of the input (e.g:
+// a book field with its own book_id), which is redundant and ambiguous.
+func checkHTTPPathDuplicateFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if !proto.HasExtension(options, restHTTPOption) {
+		return nil
+	}
+	httpRule := proto.GetExtension(options, restHTTPOption).(*googleann.HttpRule)
+	inputFields := methodDescriptor.Input().Fields()
+
+	var reported []string
+	for _, binding := range getHTTPRuleBindings(httpRule) {
+		for _, variable := range getPathTemplateVariables(getHTTPRulePath(binding)) {
+			// Only the top-level fields can be duplicated by an embedded message.
+			if inputFields.ByName(protoreflect.Name(variable)) == nil || slices.Contains(reported, variable) {
+				continue
+			}
+			for i := range inputFields.Len() {
+				field := inputFields.Get(i)
+				if field.Message() == nil || field.IsList() || field.IsMap() || field.Message().Fields().ByName(protoreflect.Name(variable)) == nil {
+					continue
+				}
+				responseWriter.AddAnnotation(
+					check.WithMessagef("%s appears both in the path and as an embedded field", variable),
+					withOptionLocation(methodDescriptor, restHTTPOption),
+				)
+				reported = append(reported, variable)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkHTTPPathVersion checks that the first version segment of the http paths
+// (e.g: v1 in /api/cluster/v1/clusters) matches the last component of the proto
+// package (e.g: qdrant.cloud.cluster.v1). Unversioned packages or paths are
+// skipped.
+func checkHTTPPathVersion(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if !proto.HasExtension(options, restHTTPOption) {
+		return nil
+	}
+	packageVersion := string(methodDescriptor.ParentFile().Package().Name())
+	if !versionRegexp.MatchString(packageVersion) {
+		return nil
+	}
+	httpRule := proto.GetExtension(options, restHTTPOption).(*googleann.HttpRule)
+
+	for _, binding := range getHTTPRuleBindings(httpRule) {
+		path := getHTTPRulePath(binding)
+		for _, segment := range strings.Split(path, "/") {
+			if !versionRegexp.MatchString(segment) {
+				continue
+			}
+			if segment != packageVersion {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("Method %q path %s doesn't match package version %s", methodDescriptor.Name(), path, packageVersion),
+					withOptionLocation(methodDescriptor, restHTTPOption),
+				)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// checkHTTPStreamingResponseBody checks that the http bindings of server
+// streaming methods set the response_body, if enabled with the
+// check_streaming_response_body option.
+func checkHTTPStreamingResponseBody(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	checkStreamingResponseBody, err := option.GetBoolValue(request.Options(), checkStreamingResponseBodyOptionKey)
+	if err != nil {
+		return err
+	}
+	options := methodDescriptor.Options()
+	if !checkStreamingResponseBody || !methodDescriptor.IsStreamingServer() || !proto.HasExtension(options, restHTTPOption) {
+		return nil
+	}
+	httpRule := proto.GetExtension(options, restHTTPOption).(*googleann.HttpRule)
+	if slices.ContainsFunc(getHTTPRuleBindings(httpRule), func(binding *googleann.HttpRule) bool { return binding.GetResponseBody() == "" }) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("streaming method %q http binding should set response_body", methodDescriptor.Name()),
+			withOptionLocation(methodDescriptor, restHTTPOption),
+		)
+	}
+
+	return nil
+}
+
+func checkMutatingMethodGet(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if !proto.HasExtension(options, restHTTPOption) {
+		return nil
+	}
+	httpRule := proto.GetExtension(options, restHTTPOption).(*googleann.HttpRule)
+	methodName := string(methodDescriptor.Name())
+	if !slices.ContainsFunc(mutatingMethodPrefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+		return nil
+	}
+	for i, binding := range getHTTPRuleBindings(httpRule) {
+		if _, ok := binding.GetPattern().(*googleann.HttpRule_Get); !ok {
+			continue
+		}
+		if i == 0 {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("mutating method %q is bound to GET, which must be idempotent/safe", methodName),
+				withOptionLocation(methodDescriptor, restHTTPOption),
+			)
+			continue
+		}
+		responseWriter.AddAnnotation(
+			check.WithMessagef("mutating method %q is bound to GET in additional binding %d, which must be idempotent/safe", methodName, i),
+			withOptionLocation(methodDescriptor, restHTTPOption),
+		)
+	}
+
+	return nil
+}
+
+// checkPermissionedService checks that at least one method of the service
+// declares permissions, unless the service is internal. Lacking a service
+// option, a service is internal when all of its methods are internal only.
+// Services without methods (e.g: placeholders) have nothing to permission.
+func checkPermissionedService(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, serviceDescriptor protoreflect.ServiceDescriptor) error {
+	requirePermissionedService, err := option.GetBoolValue(request.Options(), requirePermissionedServiceOptionKey)
+	if err != nil {
+		return err
+	}
+	if !requirePermissionedService {
+		return nil
+	}
+
+	methods := serviceDescriptor.Methods()
+	if methods.Len() == 0 {
+		return nil
+	}
+	internalOnly := true
+	for i := 0; i < methods.Len(); i++ {
+		options := methods.Get(i).Options()
+		if len(getPermissions(options)) > 0 {
+			return nil
+		}
+		methodInternalOnly, err := pluginutil.GetBoolExtension(request, options, internalOnlyExtensionName)
+		if err != nil {
+			return err
+		}
+		internalOnly = internalOnly && methodInternalOnly
+	}
+	if internalOnly {
+		return nil
+	}
+	responseWriter.AddAnnotation(
+		check.WithMessagef("service %q has no permissioned methods", serviceDescriptor.Name()),
+		check.WithDescriptor(serviceDescriptor),
+	)
+
+	return nil
+}
+
+// checkHTTPMethodSignature checks that methods bound to a REST endpoint also
+// declare a method_signature, listing the convenient arguments for clients.
+func checkHTTPMethodSignature(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if proto.HasExtension(options, restHTTPOption) && !proto.HasExtension(options, methodSignatureOption) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q has an http binding but no method_signature", methodDescriptor.Name()),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// checkMethodOrder checks that the methods of a service are declared in the
+// canonical order of their name prefixes (e.g: Create before Delete). Methods
+// without any of the prefixes are ignored.
+func checkMethodOrder(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, serviceDescriptor protoreflect.ServiceDescriptor) error {
+	methodOrder := defaultMethodOrder
+	optionValue, err := option.GetStringSliceValue(request.Options(), methodOrderOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(optionValue) > 0 {
+		methodOrder = optionValue
+	}
+
+	maxIndex := -1
+	methods := serviceDescriptor.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		index := slices.IndexFunc(methodOrder, func(prefix string) bool {
+			return strings.HasPrefix(string(method.Name()), prefix)
+		})
+		if index < 0 {
+			continue
+		}
+		if index < maxIndex {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("service %q should declare %s before %s", serviceDescriptor.Name(), methodOrder[index], methodOrder[maxIndex]),
+				check.WithDescriptor(method),
+			)
+			continue
+		}
+		maxIndex = index
+	}
+
+	return nil
+}
+
+// checkBreakingExemptJustification checks that methods exempted from the
+// permissions breaking checks have a leading comment justifying it (e.g:
+// // Exempt because: the permission was never used).
+func checkBreakingExemptJustification(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	exempt, err := pluginutil.GetBoolExtension(request, methodDescriptor.Options(), pluginutil.PermissionsBreakingExemptExtensionName)
+	if err != nil {
+		return err
+	}
+	if !exempt {
+		return nil
+	}
+	comments := methodDescriptor.ParentFile().SourceLocations().ByDescriptor(methodDescriptor).LeadingComments
+	if !strings.Contains(comments, pluginutil.PermissionsBreakingExemptCommentPrefix) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("Method %q is exempt from permissions breaking checks but has no %q comment", methodDescriptor.Name(), pluginutil.PermissionsBreakingExemptCommentPrefix),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// checkPermissionLogicConsistency tracks, for each service of a file, whether
+// each permission is required under AND or OR logic (requires_all_permissions),
+// and reports the permissions used both ways. Methods with a single permission
+// are ignored, as the logic makes no difference for them.
+func checkPermissionLogicConsistency(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		andPermissions := make(map[string]bool)
+		orPermissions := make(map[string]bool)
+		reportedPermissions := make(map[string]bool)
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			options := method.Options()
+			permissions := getPermissions(options)
+			if len(permissions) < 2 {
+				continue
+			}
+			requiresAll := !proto.HasExtension(options, requiresAllPermissionsOption) || proto.GetExtension(options, requiresAllPermissionsOption).(bool)
+			for _, perm := range permissions {
+				if requiresAll {
+					andPermissions[perm] = true
+				} else {
+					orPermissions[perm] = true
+				}
+				if andPermissions[perm] && orPermissions[perm] && !reportedPermissions[perm] {
+					reportedPermissions[perm] = true
+					responseWriter.AddAnnotation(
+						check.WithMessagef("permission %q is used under both AND and OR logic", perm),
+						check.WithDescriptor(method),
+					)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkAccountIdExpressionConsistency reports, for each service of a file, the
+// methods whose account_id_expression doesn't have the same shape as the first
+// method of the service setting one. The shape is the root the account id is
+// referenced from (e.g: resource in resource.account_id).
+func checkAccountIdExpressionConsistency(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	consistentAccountIdExpression, err := option.GetBoolValue(request.Options(), consistentAccountIdExpressionOptionKey)
+	if err != nil {
+		return err
+	}
+	if !consistentAccountIdExpression {
+		return nil
+	}
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		var firstMethod protoreflect.MethodDescriptor
+		var firstExpression string
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			accountIdExpression := strings.TrimSpace(proto.GetExtension(method.Options(), accountIdExpressionOption).(string))
+			if accountIdExpression == "" {
+				continue
+			}
+			if firstMethod == nil {
+				firstMethod = method
+				firstExpression = accountIdExpression
+				continue
+			}
+			if getAccountIdExpressionShape(accountIdExpression) != getAccountIdExpressionShape(firstExpression) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("%s uses %s but %s uses %s", method.Name(), accountIdExpression, firstMethod.Name(), firstExpression),
+					withOptionLocation(method, accountIdExpressionOption),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getAccountIdExpressionShape returns the root an account_id_expression
+// references the account id from.
+// e.g: resource.account_id -> resource, request.cluster.account_id -> request.
+func getAccountIdExpressionShape(accountIdExpression string) string {
+	root, _, _ := strings.Cut(accountIdExpression, ".")
+	return root
+}
+
+// checkPermissionRoles tallies the AND permission sets of all rpc methods in a
+// file, and reports the sets used by at least role_threshold methods.
+func checkPermissionRoles(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	roleThreshold, err := option.GetInt64Value(request.Options(), roleThresholdOptionKey)
+	if err != nil {
+		return err
+	}
+	if roleThreshold <= 0 {
+		roleThreshold = defaultRoleThreshold
+	}
+
+	// permissionSets keeps the order in which the sets are found, so the
+	// annotations are deterministic.
+	var permissionSets []string
+	methodsByPermissionSet := make(map[string][]protoreflect.MethodDescriptor)
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			options := method.Options()
+			permissions := getPermissions(options)
+			// A single permission doesn't need a role.
+			if len(permissions) < 2 {
+				continue
+			}
+			// OR permissions aren't a role, any of them grants access.
+			if proto.HasExtension(options, requiresAllPermissionsOption) && !proto.GetExtension(options, requiresAllPermissionsOption).(bool) {
+				continue
+			}
+			slices.Sort(permissions)
+			permissionSet := strings.Join(slices.Compact(permissions), " ")
+			if _, found := methodsByPermissionSet[permissionSet]; !found {
+				permissionSets = append(permissionSets, permissionSet)
+			}
+			methodsByPermissionSet[permissionSet] = append(methodsByPermissionSet[permissionSet], method)
+		}
+	}
+
+	for _, permissionSet := range permissionSets {
+		methods := methodsByPermissionSet[permissionSet]
+		if int64(len(methods)) >= roleThreshold {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("permission set [%s] is used by %d methods; consider a role", permissionSet, len(methods)),
+				check.WithDescriptor(methods[0]),
+			)
+		}
+	}
+
+	return nil
+}
+
+// getHTTPRuleBindings returns the primary binding of the given http rule,
+// followed by its additional bindings.
+func getHTTPRuleBindings(httpRule *googleann.HttpRule) []*googleann.HttpRule {
+	return slices.Concat([]*googleann.HttpRule{httpRule}, httpRule.GetAdditionalBindings())
+}
+
+// httpBindingPathName returns the name of the path of the binding at the given
+// index of getHTTPRuleBindings, to tell apart the additional bindings (e.g:
+// "additional binding 1 path") from the primary one ("path").
+func httpBindingPathName(index int) string {
+	if index == 0 {
+		return "path"
+	}
+	return fmt.Sprintf("additional binding %d path", index)
+}
+
+// getHTTPRulePath returns the path template of the given http rule.
+func getHTTPRulePath(httpRule *googleann.HttpRule) string {
+	switch pattern := httpRule.GetPattern().(type) {
+	case *googleann.HttpRule_Get:
+		return pattern.Get
+	case *googleann.HttpRule_Put:
+		return pattern.Put
+	case *googleann.HttpRule_Post:
+		return pattern.Post
+	case *googleann.HttpRule_Delete:
+		return pattern.Delete
+	case *googleann.HttpRule_Patch:
+		return pattern.Patch
+	case *googleann.HttpRule_Custom:
+		return pattern.Custom.GetPath()
+	}
+	return ""
+}
+
+// getPathTemplateVariables returns the field paths of the variables in the
+// given http path template.
+// e.g: /v1/accounts/{account_id}/books/{book.id=*} -> [account_id book.id].
+func getPathTemplateVariables(path string) []string {
+	var variables []string
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			return variables
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return variables
+		}
+		variable, _, _ := strings.Cut(path[start+1:start+end], "=")
+		variables = append(variables, strings.TrimSpace(variable))
+		path = path[start+end+1:]
+	}
+}
+
+// hasFieldPath checks if the given message has a field for the dot-separated
+// field path (e.g: book.id).
+func hasFieldPath(message protoreflect.MessageDescriptor, fieldPath string) bool {
+	return getFieldPath(message, fieldPath) != nil
+}
+
+// isReadMethod returns whether the method only reads resources (e.g: GetCluster).
+func isReadMethod(methodDescriptor protoreflect.MethodDescriptor) bool {
+	methodName := string(methodDescriptor.Name())
+	return slices.ContainsFunc(readMethodPrefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) })
+}
+
+// getFieldPath returns the field of the given message for the dot-separated
+// field path (e.g: book.id), or nil if there is no such field.
+func getFieldPath(message protoreflect.MessageDescriptor, fieldPath string) protoreflect.FieldDescriptor {
+	var field protoreflect.FieldDescriptor
+	for _, fieldName := range strings.Split(fieldPath, ".") {
+		if message == nil {
+			return nil
+		}
+		field = message.Fields().ByName(protoreflect.Name(fieldName))
+		if field == nil {
+			return nil
+		}
+		message = field.Message()
+	}
+	return field
+}
+
+// checkAccountIdExpressionTypes type-checks the account_id_expression of the
+// method as a CEL expression. The request and response variables are typed as
+// the input and output messages of the method, resource is dynamic, and the
+// fields of the input message are also declared as top-level variables (e.g:
+// cluster in cluster.account_id).
+func checkAccountIdExpressionTypes(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	options := methodDescriptor.Options()
+	if !proto.HasExtension(options, accountIdExpressionOption) {
+		return nil
+	}
+	accountIdExpression := strings.TrimSpace(proto.GetExtension(options, accountIdExpressionOption).(string))
+	if accountIdExpression == "" {
+		return nil
+	}
+	variables := getCELVariables(methodDescriptor)
+	envOptions := []cel.EnvOption{cel.TypeDescs(getFileDependencies(methodDescriptor.ParentFile())...)}
+	for name, variableType := range variables {
+		envOptions = append(envOptions, cel.Variable(name, celType(variableType)))
+	}
+	env, err := cel.NewEnv(envOptions...)
+	if err != nil {
+		return err
+	}
+
+	parsed, issues := env.Parse(accountIdExpression)
+	if issues.Err() == nil {
+		// Unknown fields are reported along with the message they're missing
+		// from, which the CEL checker doesn't tell.
+		fieldErrors := getCELFieldErrors(parsed.NativeRep().Expr(), variables)
+		for _, fieldError := range fieldErrors {
+			responseWriter.AddAnnotation(
+				check.WithMessage(fieldError),
+				withOptionLocation(methodDescriptor, accountIdExpressionOption),
+			)
+		}
+		if len(fieldErrors) > 0 {
+			return nil
+		}
+		_, issues = env.Check(parsed)
+	}
+	if issues.Err() != nil {
+		for _, issue := range issues.Errors() {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("account_id_expression doesn't type-check: %s", issue.Message),
+				withOptionLocation(methodDescriptor, accountIdExpressionOption),
+			)
+		}
+	}
+
+	return nil
+}
+
+// hasCELLiteral returns true if the given account_id_expression parses as a CEL
+// expression resulting in a string or int literal (e.g: "acc-123" or 123),
+// which hardcodes an account id. Expressions which don't parse aren't
+// reported, the accountIdExpressionTypes rule covers them.
+func hasCELLiteral(accountIdExpression string) (bool, error) {
+	// Variables don't need to be declared to parse the expression, only to
+	// type-check it.
+	env, err := cel.NewEnv()
+	if err != nil {
+		return false, err
+	}
+	parsed, issues := env.Parse(accountIdExpression)
+	if issues.Err() != nil {
+		return false, nil
+	}
+	return isCELResultLiteral(parsed.NativeRep().Expr()), nil
+}
+
+// isCELResultLiteral returns true if the given CEL expression can result in a
+// string or int literal: the expression itself, a branch of a ternary or a ||
+// fallback. Literals used otherwise (e.g: an index or a comparison operand)
+// don't hardcode an account id.
+func isCELResultLiteral(expr celast.Expr) bool {
+	switch expr.Kind() {
+	case celast.LiteralKind:
+		switch expr.AsLiteral().(type) {
+		case celtypes.String, celtypes.Int, celtypes.Uint:
+			return true
+		}
+	case celast.CallKind:
+		call := expr.AsCall()
+		switch call.FunctionName() {
+		case operators.Conditional:
+			return slices.ContainsFunc(call.Args()[1:], isCELResultLiteral)
+		case operators.LogicalOr:
+			return slices.ContainsFunc(call.Args(), isCELResultLiteral)
+		}
+	}
+	return false
+}
+
+// celVariable describes the type of a CEL variable, either a message or the
+// type of a field.
+type celVariable struct {
+	message protoreflect.MessageDescriptor
+	field   protoreflect.FieldDescriptor
+}
+
+// getCELVariables returns the variables available to the account_id_expression
+// of the given method: the fields of the input message, plus request and
+// response unless the input message has fields with the same names. A nil
+// variable is dynamic (e.g: resource).
+func getCELVariables(methodDescriptor protoreflect.MethodDescriptor) map[string]*celVariable {
+	variables := map[string]*celVariable{
+		"request":  {message: methodDescriptor.Input()},
+		"response": {message: methodDescriptor.Output()},
+		"resource": nil,
+	}
+	fields := methodDescriptor.Input().Fields()
+	for i := range fields.Len() {
+		variables[string(fields.Get(i).Name())] = &celVariable{field: fields.Get(i)}
+	}
+	return variables
+}
+
+// celType returns the CEL type of the given variable.
+func celType(variable *celVariable) *cel.Type {
+	switch {
+	case variable == nil:
+		return cel.DynType
+	case variable.message != nil:
+		return cel.ObjectType(string(variable.message.FullName()))
+	case variable.field.IsMap():
+		return cel.MapType(celFieldKindType(variable.field.MapKey()), celFieldKindType(variable.field.MapValue()))
+	case variable.field.IsList():
+		return cel.ListType(celFieldKindType(variable.field))
+	}
+	return celFieldKindType(variable.field)
+}
+
+// celFieldKindType returns the CEL type of the values of the given field,
+// ignoring its cardinality.
+func celFieldKindType(field protoreflect.FieldDescriptor) *cel.Type {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return cel.ObjectType(string(field.Message().FullName()))
+	case protoreflect.StringKind:
+		return cel.StringType
+	case protoreflect.BytesKind:
+		return cel.BytesType
+	case protoreflect.BoolKind:
+		return cel.BoolType
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		return cel.DoubleType
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return cel.UintType
+	}
+	// enums and the signed integer kinds.
+	return cel.IntType
+}
+
+// getCELFieldErrors returns the field selections of the given CEL expression
+// (e.g: cluster.account_id) which reference a field missing from the message
+// they're selected from.
+func getCELFieldErrors(expr celast.Expr, variables map[string]*celVariable) []string {
+	var fieldErrors []string
+	var resolve func(expr celast.Expr) protoreflect.MessageDescriptor
+	resolve = func(expr celast.Expr) protoreflect.MessageDescriptor {
+		switch expr.Kind() {
+		case celast.IdentKind:
+			variable := variables[expr.AsIdent()]
+			if variable == nil {
+				return nil
+			}
+			if variable.message != nil {
+				return variable.message
+			}
+			if variable.field.IsList() || variable.field.IsMap() {
+				return nil
+			}
+			return variable.field.Message()
+		case celast.SelectKind:
+			message := resolve(expr.AsSelect().Operand())
+			if message == nil {
+				return nil
+			}
+			fieldName := expr.AsSelect().FieldName()
+			field := message.Fields().ByName(protoreflect.Name(fieldName))
+			if field == nil {
+				fieldErrors = append(fieldErrors, fmt.Sprintf("no field '%s' on type %s", fieldName, message.Name()))
+				return nil
+			}
+			if field.IsList() || field.IsMap() {
+				return nil
+			}
+			return field.Message()
+		case celast.CallKind:
+			call := expr.AsCall()
+			if call.IsMemberFunction() {
+				resolve(call.Target())
+			}
+			for _, arg := range call.Args() {
+				resolve(arg)
+			}
+		case celast.ListKind:
+			for _, element := range expr.AsList().Elements() {
+				resolve(element)
+			}
+		}
+		return nil
+	}
+	resolve(expr)
+	return fieldErrors
+}
+
+// getFileDependencies returns the given file and all of its transitive imports.
+func getFileDependencies(fileDescriptor protoreflect.FileDescriptor) []any {
+	seen := map[string]struct{}{}
+	var files []any
+	var visit func(file protoreflect.FileDescriptor)
+	visit = func(file protoreflect.FileDescriptor) {
+		if _, found := seen[file.Path()]; found {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		imports := file.Imports()
+		for i := range imports.Len() {
+			visit(imports.Get(i).FileDescriptor)
+		}
+		files = append(files, file)
+	}
+	visit(fileDescriptor)
+	return files
+}
+
+// fieldKindName returns the type name of the given field (e.g: int64, or
+// repeated string).
+func fieldKindName(field protoreflect.FieldDescriptor) string {
+	kindName := field.Kind().String()
+	if field.Message() != nil {
+		kindName = string(field.Message().FullName())
+	}
+	if field.IsList() {
+		return "repeated " + kindName
+	}
+	return kindName
+}
+
+// getPermissions returns the non-empty permissions set in the given method options.
+func getPermissions(options proto.Message) []string {
+	if !proto.HasExtension(options, permissionsOption) {
+		return nil
+	}
+	var permissions []string
+	for _, perm := range proto.GetExtension(options, permissionsOption).([]string) {
+		if perm != "" {
+			permissions = append(permissions, perm)
+		}
+	}
+	return permissions
+}
+
+// withOptionLocation returns an annotation option which points to the given
+// method option when its source location is available, or to the whole method
+// otherwise.
+func withOptionLocation(methodDescriptor protoreflect.MethodDescriptor, extension protoreflect.ExtensionType) check.AddAnnotationOption {
+	sourceLocations := methodDescriptor.ParentFile().SourceLocations()
+	optionPath := slices.Concat(
+		sourceLocations.ByDescriptor(methodDescriptor).Path,
+		protoreflect.SourcePath{methodOptionsFieldNumber, int32(extension.TypeDescriptor().Number())},
+	)
+	if sourceLocations.ByPath(optionPath).Path == nil {
+		return check.WithDescriptor(methodDescriptor)
+	}
+	return check.WithFileNameAndSourcePath(methodDescriptor.ParentFile().Path(), optionPath)
+}
@@ -0,0 +1,1549 @@
+package methodoptions
+
+import (
+	"slices"
+	"testing"
+
+	"buf.build/go/bufplugin/check/checktest"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+	checktest.SpecTest(t, Spec)
+}
+
+func TestSimpleSuccess(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		// The fixture predates QDRANT_CLOUD_HTTP_METHOD_SIGNATURE, so its http
+		// bindings don't declare a method_signature.
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"HelloWorld\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   10,
+					StartColumn: 4,
+					EndLine:     14,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"OpenGoodbye\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   16,
+					StartColumn: 4,
+					EndLine:     21,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestSimpleFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.HelloWorld\" does not define the \"google.api.http\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.HelloWorld\" does not define the \"qdrant.cloud.common.v1.permissions\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.ClosedGoodbye\" does not define the \"google.api.http\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.ClosedGoodbye\" does not define the \"qdrant.cloud.common.v1.permissions\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestSimpleFailureWithOption(t *testing.T) {
+	t.Parallel()
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				methodOptionsOptionKey: []string{"qdrant.cloud.common.v1.permissions", "unknown.extension"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension key \"unknown.extension\" does not exist",
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension key \"unknown.extension\" does not exist",
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.HelloWorld\" does not define the \"qdrant.cloud.common.v1.permissions\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.ClosedGoodbye\" does not define the \"qdrant.cloud.common.v1.permissions\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+
+}
+
+func TestSimpleFailureWithOptionWrongKey(t *testing.T) {
+	t.Parallel()
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				methodOptionsOptionKey: []string{"unknown.extension"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension key \"unknown.extension\" does not exist",
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension key \"unknown.extension\" does not exist",
+			},
+		},
+	}.Run(t)
+
+}
+
+func TestPermissionsConflictSuccess(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissions_conflict_success"},
+				FilePaths: []string{"valid.proto"},
+			},
+		},
+		Spec: Spec,
+		// The fixture predates QDRANT_CLOUD_HTTP_METHOD_SIGNATURE, so its http
+		// bindings don't declare a method_signature.
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"HelloWorldWithValidAccount\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "valid.proto",
+					StartLine:   10,
+					StartColumn: 4,
+					EndLine:     15,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"HelloWorldNoPermissions\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "valid.proto",
+					StartLine:   17,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionsConflictFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissions_conflict_failure"},
+				FilePaths: []string{"invalid.proto"},
+			},
+		},
+		Spec: Spec,
+		// The fixture predates QDRANT_CLOUD_HTTP_METHOD_SIGNATURE, so its http
+		// bindings don't declare a method_signature.
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"HelloWorldWithConflict\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "invalid.proto",
+					StartLine:   10,
+					StartColumn: 4,
+					EndLine:     15,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"invalid.GreeterService.HelloWorldWithConflict\" has permissions set but account_id_expression is empty. Methods with permissions require a non-empty account_id_expression since permissions are checked in the scope of the account",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "invalid.proto",
+					StartLine:   13,
+					StartColumn: 8,
+					EndLine:     13,
+					EndColumn:   67,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestInternalOnlyHTTPConflict(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/internal_only_http"},
+				FilePaths: []string{"internal.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"internal.TelemetryService.ReportUsage\" is internal_only but declares an http binding",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "internal.proto",
+					StartLine:   13,
+					StartColumn: 8,
+					EndLine:     13,
+					EndColumn:   56,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestInternalStreamingPermissionsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/internal_streaming_permissions"},
+				FilePaths: []string{"telemetry.proto"},
+			},
+			RuleIDs: []string{internalStreamingPermissionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  internalStreamingPermissionsRuleID,
+				Message: "internal streaming method \"StreamMetrics\" sets permissions unnecessarily",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "telemetry.proto",
+					StartLine:   8,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionLiteralFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_literal"},
+				FilePaths: []string{"literal.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression appears to hardcode an account id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "literal.proto",
+					StartLine:   19,
+					StartColumn: 8,
+					EndLine:     19,
+					EndColumn:   78,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression appears to hardcode an account id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "literal.proto",
+					StartLine:   26,
+					StartColumn: 8,
+					EndLine:     26,
+					EndColumn:   70,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression appears to hardcode an account id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "literal.proto",
+					StartLine:   54,
+					StartColumn: 8,
+					EndLine:     54,
+					EndColumn:   125,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression appears to hardcode an account id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "literal.proto",
+					StartLine:   61,
+					StartColumn: 8,
+					EndLine:     61,
+					EndColumn:   100,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionResponseFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_response"},
+				FilePaths: []string{"response.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression on GetCluster references response.* which is evaluated pre-response",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "response.proto",
+					StartLine:   11,
+					StartColumn: 8,
+					EndLine:     11,
+					EndColumn:   86,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionTypeFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_type"},
+				FilePaths: []string{"types.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression references request.account_id but the field is int64",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "types.proto",
+					StartLine:   19,
+					StartColumn: 8,
+					EndLine:     19,
+					EndColumn:   85,
+				},
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "account_id_expression references cluster but the field is types.Cluster",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "types.proto",
+					StartLine:   26,
+					StartColumn: 8,
+					EndLine:     26,
+					EndColumn:   74,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionTypesFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_types"},
+				FilePaths: []string{"books.proto"},
+			},
+			RuleIDs: []string{accountIdExpressionTypesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  accountIdExpressionTypesRuleID,
+				Message: "no field 'account_id' on type Book",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "books.proto",
+					StartLine:   19,
+					StartColumn: 8,
+					EndLine:     19,
+					EndColumn:   82,
+				},
+			},
+			{
+				RuleID:  accountIdExpressionTypesRuleID,
+				Message: "account_id_expression doesn't type-check: found no matching overload for '_+_' applied to '(int, string)'",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "books.proto",
+					StartLine:   33,
+					StartColumn: 8,
+					EndLine:     33,
+					EndColumn:   96,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestRequireAccountIdExpressionPrefixes(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_prefixes"},
+				FilePaths: []string{"metrics.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+			Options: map[string]any{
+				methodOptionsOptionKey:                      []string{"google.api.http"},
+				requireAccountIdExpressionPrefixesOptionKey: []string{"ListMetrics"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"ListMetrics\" must set account_id_expression",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "metrics.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestMethodMessagePackageFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/message_package_failure"},
+				FilePaths: []string{"service.proto"},
+			},
+			RuleIDs: []string{methodMessagePackageRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodMessagePackageRuleID,
+				Message: "Method \"this.v1.GreeterService.HelloWorld\" output is in package \"other.v1\" not the service package \"this.v1\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   8,
+					StartColumn: 4,
+					EndLine:     9,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestMethodMessagePackageWithOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/message_package_failure"},
+				FilePaths: []string{"service.proto"},
+			},
+			RuleIDs: []string{methodMessagePackageRuleID},
+			Options: map[string]any{
+				methodMessagePackageAllowlistOptionKey: []string{"google.protobuf", "other"},
+			},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestPermissionVerbsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_verbs"},
+				FilePaths: []string{"verbs.proto"},
+			},
+			RuleIDs: []string{permissionVerbsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionVerbsRuleID,
+				Message: "permission \"Read:cluster\" uses unknown verb \"Read\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "verbs.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     19,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  permissionVerbsRuleID,
+				Message: "permission \"view:cluster\" uses unknown verb \"view\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "verbs.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     19,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionVerbsWithOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_verbs"},
+				FilePaths: []string{"verbs.proto"},
+			},
+			RuleIDs: []string{permissionVerbsRuleID},
+			Options: map[string]any{
+				permissionVerbsOptionKey: []string{"read", "write", "view"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionVerbsRuleID,
+				Message: "permission \"Read:cluster\" uses unknown verb \"Read\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "verbs.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     19,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestKnownPermissionsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/known_permissions"},
+				FilePaths: []string{"permissions.proto"},
+			},
+			RuleIDs: []string{knownPermissionsRuleID},
+			Options: map[string]any{
+				knownPermissionsOptionKey: []string{"read:cluster", "write:cluster"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  knownPermissionsRuleID,
+				Message: "unknown permission \"raed:cluster\", did you mean \"read:cluster\"?",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "permissions.proto",
+					StartLine:   13,
+					StartColumn: 4,
+					EndLine:     16,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  knownPermissionsRuleID,
+				Message: "unknown permission \"restore:backup\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "permissions.proto",
+					StartLine:   18,
+					StartColumn: 4,
+					EndLine:     21,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b     string
+		distance int
+	}{
+		{a: "read:cluster", b: "read:cluster", distance: 0},
+		{a: "raed:cluster", b: "read:cluster", distance: 2},
+		{a: "read:clusters", b: "read:cluster", distance: 1},
+		{a: "", b: "read", distance: 4},
+		{a: "kitten", b: "sitting", distance: 3},
+	} {
+		if actual := levenshteinDistance(tc.a, tc.b); actual != tc.distance {
+			t.Errorf("levenshteinDistance(%q, %q) = %d, expected %d", tc.a, tc.b, actual, tc.distance)
+		}
+	}
+}
+
+func TestHTTPPathFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_path_fields"},
+				FilePaths: []string{"paths.proto"},
+			},
+			RuleIDs: []string{httpPathFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpPathFieldsRuleID,
+				Message: "http path references {book_id} but input has no such field",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "paths.proto",
+					StartLine:   19,
+					StartColumn: 8,
+					EndLine:     19,
+					EndColumn:   90,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestHTTPPathDuplicateFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_path_duplicate_fields"},
+				FilePaths: []string{"paths.proto"},
+			},
+			RuleIDs: []string{httpPathDuplicateFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpPathDuplicateFieldsRuleID,
+				Message: "book_id appears both in the path and as an embedded field",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "paths.proto",
+					StartLine:   18,
+					StartColumn: 8,
+					EndLine:     18,
+					EndColumn:   78,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestHTTPPathVersionFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_path_version"},
+				FilePaths: []string{"books.proto"},
+			},
+			RuleIDs: []string{httpPathVersionRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpPathVersionRuleID,
+				Message: "Method \"ListBooks\" path /v1/books doesn't match package version v2",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "books.proto",
+					StartLine:   18,
+					StartColumn: 8,
+					EndLine:     18,
+					EndColumn:   54,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestMutatingMethodGetFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/mutating_method_get"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{mutatingMethodGetRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  mutatingMethodGetRuleID,
+				Message: "mutating method \"DeleteBook\" is bound to GET, which must be idempotent/safe",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   18,
+					StartColumn: 8,
+					EndLine:     18,
+					EndColumn:   94,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionRolesFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_roles"},
+				FilePaths: []string{"roles.proto"},
+			},
+			RuleIDs: []string{permissionRolesRuleID},
+			Options: map[string]any{
+				roleThresholdOptionKey: int64(3),
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionRolesRuleID,
+				Message: "permission set [read:cluster write:cluster] is used by 3 methods; consider a role",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "roles.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     13,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionRolesBelowDefaultThreshold(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_roles"},
+				FilePaths: []string{"roles.proto"},
+			},
+			RuleIDs: []string{permissionRolesRuleID},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestPermissionedServiceFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissioned_service"},
+				FilePaths: []string{"services.proto"},
+			},
+			RuleIDs: []string{permissionedServiceRuleID},
+			Options: map[string]any{
+				requirePermissionedServiceOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionedServiceRuleID,
+				Message: "service \"PublicService\" has no permissioned methods",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "services.proto",
+					StartLine:   9,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionedServiceWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissioned_service"},
+				FilePaths: []string{"services.proto"},
+			},
+			RuleIDs: []string{permissionedServiceRuleID},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestPermissionedServiceWithoutMethods(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissioned_service_empty"},
+				FilePaths: []string{"services.proto"},
+			},
+			RuleIDs: []string{permissionedServiceRuleID},
+			Options: map[string]any{
+				requirePermissionedServiceOptionKey: true,
+			},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestHTTPStreamingResponseBodyFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_streaming_response_body"},
+				FilePaths: []string{"streams.proto"},
+			},
+			RuleIDs: []string{httpStreamingResponseBodyRuleID},
+			Options: map[string]any{
+				checkStreamingResponseBodyOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpStreamingResponseBodyRuleID,
+				Message: "streaming method \"StreamLogs\" http binding should set response_body",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "streams.proto",
+					StartLine:   12,
+					StartColumn: 8,
+					EndLine:     12,
+					EndColumn:   61,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestHTTPStreamingResponseBodyWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_streaming_response_body"},
+				FilePaths: []string{"streams.proto"},
+			},
+			RuleIDs: []string{httpStreamingResponseBodyRuleID},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestRegisterExtensions(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				registerExtensionsOptionKey: []string{"qdrant.cloud.common.v1.requires_authentication"},
+				methodOptionsOptionKey:      []string{"qdrant.cloud.common.v1.requires_authentication"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "Method \"simple.GreeterService.HelloWorld\" does not define the \"qdrant.cloud.common.v1.requires_authentication\" option",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   9,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestRegisterExtensionsUnknown(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{methodOptionsRuleID},
+			Options: map[string]any{
+				registerExtensionsOptionKey: []string{"unknown.extension"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension \"unknown.extension\" set in the register_extensions option can't be resolved from the proto registry",
+			},
+			{
+				RuleID:  methodOptionsRuleID,
+				Message: "extension \"unknown.extension\" set in the register_extensions option can't be resolved from the proto registry",
+			},
+		},
+	}.Run(t)
+}
+
+// TestDisableRulesEnv can't run in parallel, because it sets an environment variable.
+func TestDisableRulesEnv(t *testing.T) {
+	t.Setenv(pluginutil.DisableRulesEnvVar, methodOptionsRuleID)
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - the rule is disabled via the environment variable
+	}.Run(t)
+}
+
+func TestStrictProfile(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissioned_service"},
+				FilePaths: []string{"services.proto"},
+			},
+			RuleIDs: []string{permissionedServiceRuleID},
+			Options: map[string]any{
+				pluginutil.ProfileOptionKey: "strict",
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionedServiceRuleID,
+				Message: "service \"PublicService\" has no permissioned methods",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "services.proto",
+					StartLine:   9,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+// TestStrictProfileOptions checks that the strict profile enables all of the
+// optional checks toggled by a bool option, so new ones aren't left out of it.
+func TestStrictProfileOptions(t *testing.T) {
+	t.Parallel()
+
+	// nonOptInOptionKeys configure the checks with a value, or relax them,
+	// rather than enabling an optional check.
+	nonOptInOptionKeys := []string{
+		pluginutil.ProfileOptionKey,
+		methodOptionsOptionKey,
+		registerExtensionsOptionKey,
+		requireAccountIdExpressionPrefixesOptionKey,
+		methodMessagePackageAllowlistOptionKey,
+		permissionVerbsOptionKey,
+		permissionVerbPrefixesOptionKey,
+		knownPermissionsOptionKey,
+		roleThresholdOptionKey,
+		methodOrderOptionKey,
+		permissionVerbPairsOptionKey,
+	}
+	for _, key := range optionKeys {
+		if slices.Contains(nonOptInOptionKeys, key) {
+			continue
+		}
+		if value, found := profiles["strict"][key]; !found || value != true {
+			t.Errorf("option %q is not enabled by the strict profile", key)
+		}
+	}
+}
+
+func TestLenientProfile(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permissioned_service"},
+				FilePaths: []string{"services.proto"},
+			},
+			RuleIDs: []string{permissionedServiceRuleID},
+			Options: map[string]any{
+				pluginutil.ProfileOptionKey: "lenient",
+			},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
+
+func TestPermissionMethodPrefixFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_method_prefix"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionMethodPrefixRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionMethodPrefixRuleID,
+				Message: "Method \"UpdateBook\" has a delete permission but isn't a Delete method",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     19,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  permissionMethodPrefixRuleID,
+				Message: "Method \"PurgeBook\" has a delete permission but isn't a Delete method",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   21,
+					StartColumn: 4,
+					EndLine:     25,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionVerbPrefixesOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_method_prefix"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionMethodPrefixRuleID},
+			Options: map[string]any{
+				permissionVerbPrefixesOptionKey: []string{"delete=Delete,Purge"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionMethodPrefixRuleID,
+				Message: "Method \"UpdateBook\" has a delete permission but isn't a Delete/Purge method",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     19,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestHTTPAdditionalBindingsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_additional_bindings"},
+				FilePaths: []string{"bindings.proto"},
+			},
+			RuleIDs: []string{httpPathFieldsRuleID, mutatingMethodGetRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpPathFieldsRuleID,
+				Message: "http additional binding 1 path references {id} but input has no such field",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "bindings.proto",
+					StartLine:   21,
+					StartColumn: 8,
+					EndLine:     24,
+					EndColumn:   10,
+				},
+			},
+			{
+				RuleID:  mutatingMethodGetRuleID,
+				Message: "mutating method \"DeleteBook\" is bound to GET in additional binding 1, which must be idempotent/safe",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "bindings.proto",
+					StartLine:   21,
+					StartColumn: 8,
+					EndLine:     24,
+					EndColumn:   10,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestSortedPermissionsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/sorted_permissions"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{sortedPermissionsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  sortedPermissionsRuleID,
+				Message: "permissions on \"UpdateBook\" are not sorted",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   16,
+					StartColumn: 4,
+					EndLine:     21,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestBreakingExemptJustificationFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/breaking_exempt"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{breakingExemptJustificationRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  breakingExemptJustificationRuleID,
+				Message: "Method \"DeleteBook\" is exempt from permissions breaking checks but has no \"Exempt because:\" comment",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   18,
+					StartColumn: 4,
+					EndLine:     23,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestMethodOrderFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/method_order"},
+				FilePaths: []string{"service.proto"},
+			},
+			RuleIDs: []string{methodOrderRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOrderRuleID,
+				Message: "service \"BookService\" should declare Create before Delete",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   10,
+					StartColumn: 4,
+					EndLine:     10,
+					EndColumn:   76,
+				},
+			},
+			{
+				RuleID:  methodOrderRuleID,
+				Message: "service \"BookService\" should declare Get before Delete",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   13,
+					StartColumn: 4,
+					EndLine:     13,
+					EndColumn:   73,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestMethodOrderCustomOrder(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/method_order"},
+				FilePaths: []string{"service.proto"},
+			},
+			RuleIDs: []string{methodOrderRuleID},
+			Options: map[string]any{
+				methodOrderOptionKey: []string{"Delete", "Create"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodOrderRuleID,
+				Message: "service \"ShelfService\" should declare Delete before Create",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   77,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestHTTPMethodSignatureFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/http_method_signature"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{httpMethodSignatureRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  httpMethodSignatureRuleID,
+				Message: "Method \"DeleteBook\" has an http binding but no method_signature",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   16,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionVerbPairsFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_verb_pairs"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionVerbPairsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionVerbPairsRuleID,
+				Message: "Method \"RestartCluster\" requires write:cluster but not read:cluster",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   14,
+					StartColumn: 4,
+					EndLine:     17,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionVerbPairsWithOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_verb_pairs"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionVerbPairsRuleID},
+			Options: map[string]any{
+				permissionVerbPairsOptionKey: []string{"read=manage"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionVerbPairsRuleID,
+				Message: "Method \"UpdateCluster\" requires read:cluster but not manage:cluster",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   8,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionResourcesFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_resources"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionResourcesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionResourcesRuleID,
+				Message: "Method \"RestoreBackup\" requires AND permissions across resources [cluster backup]; verify intent",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   14,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestPermissionLogicConsistencyFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/permission_logic_consistency"},
+				FilePaths: []string{"methods.proto"},
+			},
+			RuleIDs: []string{permissionLogicConsistencyRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionLogicConsistencyRuleID,
+				Message: "permission \"read:cluster\" is used under both AND and OR logic",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "methods.proto",
+					StartLine:   13,
+					StartColumn: 4,
+					EndLine:     18,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionConsistencyFailure(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_consistency"},
+				FilePaths: []string{"books.proto"},
+			},
+			RuleIDs: []string{accountIdExpressionConsistencyRuleID},
+			Options: map[string]any{
+				consistentAccountIdExpressionOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  accountIdExpressionConsistencyRuleID,
+				Message: "GetBook uses resource.account_id but ListBooks uses request.account_id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "books.proto",
+					StartLine:   17,
+					StartColumn: 8,
+					EndLine:     17,
+					EndColumn:   86,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAccountIdExpressionConsistencyWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_expression_consistency"},
+				FilePaths: []string{"books.proto"},
+			},
+			RuleIDs: []string{accountIdExpressionConsistencyRuleID},
+		},
+		Spec: Spec,
+	}.Run(t)
+}
@@ -0,0 +1,362 @@
+// Package permissionsbreaking implements a plugin that checks for breaking changes in permissions.
+// The plugin detects when method permissions change, which could break existing
+// client code that depends on certain access levels.
+//
+// The plugin handles both AND and OR permission logic via the requires_all_permissions option:
+// - requires_all_permissions=true (default): ALL permissions must be met (AND logic)
+// - requires_all_permissions=false: ANY ONE permission must be met (OR logic)
+//
+// Breaking changes detected:
+// - Adding permissions to methods that previously had none (restricts access)
+// - Removing all permissions from methods (changes access model)
+// - Changing requires_all_permissions from false to true (OR to AND, more restrictive)
+// - For AND permissions (requires_all_permissions=true): ANY change to permissions
+// - For OR permissions (requires_all_permissions=false): REMOVING permissions
+// - Narrowing a wildcard permission (e.g: read:* to read:cluster)
+//
+// Methods with the qdrant.cloud.common.v1.permissions_breaking_exempt option are
+// exempted from these checks. The lint rules of the method options plugin
+// require the exemption to be justified with a "// Exempt because:" comment.
+//
+// Non-breaking changes (not reported):
+// - New methods with permissions (handled automatically by buf framework)
+// - Adding or removing non-restrictive permissions (see the non_restrictive_permissions option)
+// - Adding or removing ignored permissions (see the ignore_permissions option)
+// - Changing requires_all_permissions from true to false (AND to OR, more permissive)
+// - For OR permissions (requires_all_permissions=false): ADDING permissions
+// - Broadening a permission into a wildcard one (e.g: read:cluster to read:*)
+//
+// To use this plugin:
+//
+//	# buf.yaml
+//	version: v2
+//	breaking:
+//	  use:
+//	   - QDRANT_CLOUD_PERMISSIONS_BREAKING
+//	plugins:
+//	  - plugin: buf-plugin-permissions-breaking
+//	    # Uncomment in case you need to configure the list of non-restrictive permissions.
+//	    # options:
+//	    #  non_restrictive_permissions:
+//	    #    - "read:public"
+//	    #  # Permissions ignored on both sides of the comparison.
+//	    #  ignore_permissions:
+//	    #    - "internal:debug"
+package permissionsbreaking
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checkutil"
+	"buf.build/go/bufplugin/info"
+	"buf.build/go/bufplugin/option"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	commonv1 "github.com/qdrant/qdrant-cloud-public-api/gen/go/qdrant/cloud/common/v1"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+const (
+	permissionsBreakingRuleID = "QDRANT_CLOUD_PERMISSIONS_BREAKING"
+	// nonRestrictivePermissionsOptionKey is the option key to set the list of
+	// permissions which don't restrict access when added to a method.
+	nonRestrictivePermissionsOptionKey = "non_restrictive_permissions"
+	// ignorePermissionsOptionKey is the option key to set the list of
+	// permissions which are ignored on both sides of the comparison (e.g:
+	// environment-specific permissions).
+	ignorePermissionsOptionKey = "ignore_permissions"
+	// permissionsFieldNumber is the expected field number of the permissions
+	// extension. Renumbering it would break all of the serialized options.
+	permissionsFieldNumber protoreflect.FieldNumber = 50001
+)
+
+// PermissionConfig holds the permission configuration for a method.
+type PermissionConfig struct {
+	Permissions []string
+	RequiresAll bool // true = AND (default), false = OR
+}
+
+var (
+	permissionsBreakingRuleSpec = &check.RuleSpec{
+		ID:      permissionsBreakingRuleID,
+		Default: true,
+		Purpose: `Checks for breaking changes in method permissions.`,
+		Type:    check.RuleTypeBreaking,
+		Handler: withPermissionsExtensionValidation(checkutil.NewMethodPairRuleHandler(checkPermissionsBreaking, checkutil.WithoutImports())),
+	}
+	// optionKeys are the plugin-specific option keys recognized by the plugin.
+	optionKeys = []string{nonRestrictivePermissionsOptionKey, ignorePermissionsOptionKey}
+	// Spec is the check spec of the plugin, served by its command and run
+	// in-process by the analysis package.
+	Spec = pluginutil.WithDisableRulesEnv(pluginutil.WithRuleMetadata(pluginutil.WithStrictOptions(&check.Spec{
+		Rules: []*check.RuleSpec{
+			permissionsBreakingRuleSpec,
+		},
+		Info: &info.Spec{
+			Documentation: `A plugin that checks for breaking changes in method permissions.`,
+			SPDXLicenseID: "",
+			LicenseURL:    "",
+		},
+	}, optionKeys...), optionKeys...))
+	permissionsOption            = commonv1.E_Permissions
+	requiresAllPermissionsOption = commonv1.E_RequiresAllPermissions
+)
+
+func checkPermissionsBreaking(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor, againstMethodDescriptor protoreflect.MethodDescriptor) error {
+	nonRestrictivePermissions, err := option.GetStringSliceValue(request.Options(), nonRestrictivePermissionsOptionKey)
+	if err != nil {
+		return err
+	}
+	ignorePermissions, err := option.GetStringSliceValue(request.Options(), ignorePermissionsOptionKey)
+	if err != nil {
+		return err
+	}
+	// Exempt methods are justified with a comment, see the method options plugin.
+	exempt, err := pluginutil.GetBoolExtension(request, methodDescriptor.Options(), pluginutil.PermissionsBreakingExemptExtensionName)
+	if err != nil {
+		return err
+	}
+	if exempt {
+		return nil
+	}
+	againstConfig := getMethodPermissionConfig(againstMethodDescriptor, ignorePermissions)
+	currentConfig := getMethodPermissionConfig(methodDescriptor, ignorePermissions)
+
+	// Check for breaking changes based on permission logic
+	if isBreakingChange(againstConfig, currentConfig, nonRestrictivePermissions) {
+		var message string
+		if len(currentConfig.Permissions) == 0 {
+			message = fmt.Sprintf("Method %q had permissions %v but now has no permissions, this is a breaking change",
+				methodDescriptor.FullName(), againstConfig.Permissions)
+		} else if len(againstConfig.Permissions) == 0 {
+			message = fmt.Sprintf("Method %q had no permissions but now requires permissions %v, this is a breaking change",
+				methodDescriptor.FullName(), currentConfig.Permissions)
+		} else {
+			requiresAllChanged := againstConfig.RequiresAll != currentConfig.RequiresAll
+			if requiresAllChanged {
+				message = fmt.Sprintf("Method %q permissions logic changed from requires_all=%t to requires_all=%t with permissions %v to %v, this is a breaking change",
+					methodDescriptor.FullName(), againstConfig.RequiresAll, currentConfig.RequiresAll, againstConfig.Permissions, currentConfig.Permissions)
+			} else {
+				message = fmt.Sprintf("Method %q permissions changed from %v to %v (requires_all=%t), this is a breaking change",
+					methodDescriptor.FullName(), againstConfig.Permissions, currentConfig.Permissions, currentConfig.RequiresAll)
+			}
+		}
+		responseWriter.AddAnnotation(
+			check.WithMessage(message),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// withPermissionsExtensionValidation wraps the given handler, so it fails
+// loudly if the permissions extension was renumbered, either by a bump of the
+// public api or in the checked files, as the permissions wouldn't be read. The
+// extension is validated once per request, rather than for each method.
+func withPermissionsExtensionValidation(handler check.RuleHandler) check.RuleHandler {
+	return check.RuleHandlerFunc(func(ctx context.Context, responseWriter check.ResponseWriter, request check.Request) error {
+		if err := validateExtensionFieldNumber(permissionsOption, permissionsFieldNumber); err != nil {
+			return err
+		}
+		if extension := pluginutil.FindExtension(request, permissionsOption.TypeDescriptor().FullName()); extension != nil {
+			if err := validateExtensionFieldNumber(extension, permissionsFieldNumber); err != nil {
+				return err
+			}
+		}
+		return handler.Handle(ctx, responseWriter, request)
+	})
+}
+
+// validateExtensionFieldNumber checks that the given extension has the expected
+// field number.
+func validateExtensionFieldNumber(extensionType protoreflect.ExtensionType, expected protoreflect.FieldNumber) error {
+	extension := extensionType.TypeDescriptor()
+	if extension.Number() != expected {
+		return fmt.Errorf("extension %q has field number %d, expected %d", extension.FullName(), extension.Number(), expected)
+	}
+	return nil
+}
+
+// getMethodPermissionConfig extracts the permission configuration from a method descriptor.
+// The ignored permissions are left out of the configuration.
+func getMethodPermissionConfig(methodDescriptor protoreflect.MethodDescriptor, ignorePermissions []string) PermissionConfig {
+	options := methodDescriptor.Options()
+
+	// Extract permissions
+	var permissions []string
+	if proto.HasExtension(options, permissionsOption) {
+		permissionsRaw := proto.GetExtension(options, permissionsOption)
+		if permissionsSlice, ok := permissionsRaw.([]string); ok {
+			// Filter out empty and ignored permissions and sort for consistent comparison
+			for _, perm := range permissionsSlice {
+				if strings.TrimSpace(perm) != "" && !slices.Contains(ignorePermissions, strings.TrimSpace(perm)) {
+					permissions = append(permissions, strings.TrimSpace(perm))
+				}
+			}
+			sort.Strings(permissions)
+		}
+	}
+
+	// Extract requires_all_permissions (defaults to true)
+	requiresAll := true // Default to AND behavior
+	if proto.HasExtension(options, requiresAllPermissionsOption) {
+		if val, ok := proto.GetExtension(options, requiresAllPermissionsOption).(bool); ok {
+			requiresAll = val
+		}
+	}
+
+	return normalizePermissionConfig(PermissionConfig{
+		Permissions: permissions,
+		RequiresAll: requiresAll,
+	})
+}
+
+// normalizePermissionConfig returns the given permission configuration, with
+// an absent permissions extension and an empty list of permissions treated
+// identically: requires_all_permissions is meaningless without permissions, so
+// it falls back to the default (AND).
+func normalizePermissionConfig(config PermissionConfig) PermissionConfig {
+	if len(config.Permissions) == 0 {
+		return PermissionConfig{
+			Permissions: nil,
+			RequiresAll: true,
+		}
+	}
+	return config
+}
+
+// isBreakingChange determines if a permission configuration change is breaking.
+// Non-restrictive permissions (e.g: read:public) are ignored when checking if
+// added permissions restrict the access to a method.
+func isBreakingChange(against, current PermissionConfig, nonRestrictivePermissions []string) bool {
+	against = normalizePermissionConfig(against)
+	current = normalizePermissionConfig(current)
+
+	// If both configs are identical, no breaking change
+	if configsEqual(against, current) {
+		return false
+	}
+
+	// Handle the case where permissions are added to a method that had none
+	if len(against.Permissions) == 0 && len(current.Permissions) > 0 {
+		// Adding permissions to a previously unrestricted method is breaking,
+		// unless all of them are non-restrictive
+		return len(withoutPermissions(current.Permissions, nonRestrictivePermissions)) > 0
+	}
+
+	// Handle the case where permissions are removed completely
+	if len(against.Permissions) > 0 && len(current.Permissions) == 0 {
+		return true // Removing all permissions changes the access model
+	}
+
+	// If requires_all_permissions logic changed:
+	// - true -> false (AND to OR): non-breaking (more permissive)
+	// - false -> true (OR to AND): breaking (more restrictive)
+	if against.RequiresAll != current.RequiresAll {
+		if against.RequiresAll && !current.RequiresAll {
+			// Changed from AND to OR - non-breaking (more permissive)
+			return false
+		} else {
+			// Changed from OR to AND - breaking (more restrictive)
+			return true
+		}
+	}
+
+	// For methods that had permissions before and still have permissions
+	if len(against.Permissions) > 0 && len(current.Permissions) > 0 {
+		if against.RequiresAll {
+			// AND logic: ANY change is breaking (both adding and removing permissions),
+			// except broadening into wildcard permissions, and changes of
+			// non-restrictive permissions are ignored
+			againstPermissions := withoutPermissions(against.Permissions, nonRestrictivePermissions)
+			currentPermissions := withoutPermissions(current.Permissions, nonRestrictivePermissions)
+			return hasRemovedPermissions(againstPermissions, currentPermissions) ||
+				hasAddedPermissions(againstPermissions, currentPermissions)
+		} else {
+			// OR logic: Only removing permissions is breaking, adding is non-breaking
+			return hasRemovedPermissions(against.Permissions, current.Permissions)
+		}
+	}
+
+	return false
+}
+
+// configsEqual checks if two permission configurations are identical.
+func configsEqual(a, b PermissionConfig) bool {
+	return a.RequiresAll == b.RequiresAll && permissionsEqual(a.Permissions, b.Permissions)
+}
+
+// hasRemovedPermissions checks if any permissions were removed (for OR logic).
+// A permission subsumed by a current one (e.g: read:cluster by read:*) isn't
+// removed.
+func hasRemovedPermissions(previous, current []string) bool {
+	for _, perm := range previous {
+		if !slices.ContainsFunc(current, func(currentPerm string) bool { return permissionSubsumes(currentPerm, perm) }) {
+			return true // Found a permission that was removed
+		}
+	}
+	return false
+}
+
+// hasAddedPermissions checks if any permissions were added. A permission which
+// subsumes a previous one (e.g: read:* broadening read:cluster) isn't added.
+func hasAddedPermissions(previous, current []string) bool {
+	for _, perm := range current {
+		if !slices.ContainsFunc(previous, func(previousPerm string) bool { return permissionSubsumes(perm, previousPerm) }) {
+			return true // Found a permission that was added
+		}
+	}
+	return false
+}
+
+// permissionSubsumes checks if permission a grants at least the access of
+// permission b, taking wildcards into account.
+// e.g: read:* subsumes read:cluster, but read:cluster doesn't subsume read:*.
+func permissionSubsumes(a, b string) bool {
+	if a == b {
+		return true
+	}
+	aVerb, aResource, aFound := strings.Cut(a, ":")
+	bVerb, bResource, bFound := strings.Cut(b, ":")
+	if !aFound || !bFound {
+		return false
+	}
+	return (aVerb == "*" || aVerb == bVerb) && (aResource == "*" || aResource == bResource)
+}
+
+// withoutPermissions returns the given permissions, excluding the ones to remove.
+func withoutPermissions(permissions, toRemove []string) []string {
+	removeSet := make(map[string]bool)
+	for _, perm := range toRemove {
+		removeSet[perm] = true
+	}
+
+	var result []string
+	for _, perm := range permissions {
+		if !removeSet[perm] {
+			result = append(result, perm)
+		}
+	}
+	return result
+}
+
+func permissionsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
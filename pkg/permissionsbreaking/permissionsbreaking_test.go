@@ -0,0 +1,597 @@
+package permissionsbreaking
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checktest"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+	checktest.SpecTest(t, Spec)
+}
+
+func TestBreakingChange(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/breaking_change/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/breaking_change/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.TestMethod\" permissions changed from [read:test write:test] to [read:test] (requires_all=true), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     11,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestNewMethodNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/new_method/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/new_method/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - new methods with permissions should not be breaking
+	}.Run(t)
+}
+
+func TestAddPermissionsBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/add_permissions/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/add_permissions/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.PublicMethod\" had no permissions but now requires permissions [read:restricted], this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     11,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestOrPermissionsAddNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_permissions_add_non_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_permissions_add_non_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - adding permissions with OR logic is non-breaking
+	}.Run(t)
+}
+
+func TestOrPermissionsRemoveBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_permissions_remove_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_permissions_remove_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.FlexibleMethod\" permissions changed from [read:advanced read:basic] to [read:basic] (requires_all=false), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     12,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAndToOrNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/and_to_or_non_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/and_to_or_non_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - changing from AND to OR is non-breaking (more permissive)
+	}.Run(t)
+}
+
+func TestOrToAndBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_to_and_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/or_to_and_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.MyMethod\" permissions logic changed from requires_all=false to requires_all=true with permissions [read:data write:data] to [read:data write:data], this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     13,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestNonRestrictiveAddNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/non_restrictive_add_non_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/non_restrictive_add_non_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+			Options: map[string]any{
+				nonRestrictivePermissionsOptionKey: []string{"read:public"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - adding a non-restrictive permission with AND logic is non-breaking
+	}.Run(t)
+}
+
+func TestNonRestrictiveAddBreakingWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/non_restrictive_add_non_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/non_restrictive_add_non_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.MyMethod\" permissions changed from [read:data] to [read:data read:public] (requires_all=true), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     12,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestIgnorePermissionsNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/ignore_permissions/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/ignore_permissions/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+			Options: map[string]any{
+				ignorePermissionsOptionKey: []string{"internal:debug"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - only the ignored permission was removed or added
+	}.Run(t)
+}
+
+func TestIgnorePermissionsBreakingWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/ignore_permissions/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/ignore_permissions/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.MyMethod\" permissions changed from [internal:debug read:data] to [read:data] (requires_all=true), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     11,
+					EndColumn:   3,
+				},
+			},
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.OtherMethod\" had no permissions but now requires permissions [internal:debug], this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   13,
+					StartColumn: 2,
+					EndLine:     15,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestAbsentVsEmptyNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/absent_vs_empty/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/absent_vs_empty/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - an absent permissions extension and an empty list of permissions are identical
+	}.Run(t)
+}
+
+func TestAbsentVsEmptyOrBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/absent_vs_empty_or/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/absent_vs_empty_or/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.AbsentToOr\" had no permissions but now requires permissions [write:cluster], this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     12,
+					EndColumn:   3,
+				},
+			},
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.EmptyOrToOr\" had no permissions but now requires permissions [write:cluster], this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   14,
+					StartColumn: 2,
+					EndLine:     17,
+					EndColumn:   3,
+				},
+			},
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.OrToAbsent\" had permissions [write:cluster] but now has no permissions, this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   19,
+					StartColumn: 2,
+					EndLine:     20,
+					EndColumn:   3,
+				},
+			},
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.OrToEmptyOr\" had permissions [write:cluster] but now has no permissions, this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   22,
+					StartColumn: 2,
+					EndLine:     25,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestIsBreakingChangeAbsentVsEmpty(t *testing.T) {
+	t.Parallel()
+
+	absent := PermissionConfig{Permissions: nil, RequiresAll: true}
+	emptyAnd := PermissionConfig{Permissions: []string{}, RequiresAll: true}
+	emptyOr := PermissionConfig{Permissions: []string{}, RequiresAll: false}
+	absentOr := PermissionConfig{Permissions: nil, RequiresAll: false}
+	restricted := PermissionConfig{Permissions: []string{"read:test"}, RequiresAll: true}
+	restrictedOr := PermissionConfig{Permissions: []string{"read:test"}, RequiresAll: false}
+
+	for name, tc := range map[string]struct {
+		against  PermissionConfig
+		current  PermissionConfig
+		breaking bool
+	}{
+		"absent to empty":             {against: absent, current: emptyAnd, breaking: false},
+		"empty to absent":             {against: emptyAnd, current: absent, breaking: false},
+		"absent to empty or":          {against: absent, current: emptyOr, breaking: false},
+		"empty or to absent":          {against: emptyOr, current: absent, breaking: false},
+		"absent to permissions":       {against: absent, current: restricted, breaking: true},
+		"empty or to permissions":     {against: emptyOr, current: restricted, breaking: true},
+		"permissions to absent":       {against: restricted, current: absent, breaking: true},
+		"permissions to empty or":     {against: restricted, current: emptyOr, breaking: true},
+		"absent to or permissions":    {against: absent, current: restrictedOr, breaking: true},
+		"absent or to or permissions": {against: absentOr, current: restrictedOr, breaking: true},
+		"empty or to or permissions":  {against: emptyOr, current: restrictedOr, breaking: true},
+		"or permissions to absent":    {against: restrictedOr, current: absent, breaking: true},
+		"or permissions to absent or": {against: restrictedOr, current: absentOr, breaking: true},
+		"or permissions to empty or":  {against: restrictedOr, current: emptyOr, breaking: true},
+	} {
+		if actual := isBreakingChange(tc.against, tc.current, nil); actual != tc.breaking {
+			t.Errorf("%s: isBreakingChange() = %t, expected %t", name, actual, tc.breaking)
+		}
+	}
+}
+
+func TestWildcardNarrowingBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/wildcard_narrowing_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/wildcard_narrowing_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.GetCluster\" permissions changed from [read:*] to [read:cluster] (requires_all=true), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   9,
+					StartColumn: 2,
+					EndLine:     11,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestWildcardWideningNonBreaking(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/wildcard_widening_non_breaking/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/wildcard_widening_non_breaking/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - broadening a permission into a wildcard one should not be breaking
+	}.Run(t)
+}
+
+func TestPermissionSubsumes(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b     string
+		subsumes bool
+	}{
+		{a: "read:cluster", b: "read:cluster", subsumes: true},
+		{a: "read:*", b: "read:cluster", subsumes: true},
+		{a: "*:cluster", b: "write:cluster", subsumes: true},
+		{a: "*:*", b: "write:cluster", subsumes: true},
+		{a: "read:cluster", b: "read:*", subsumes: false},
+		{a: "read:*", b: "write:cluster", subsumes: false},
+		{a: "read:cluster", b: "read:backup", subsumes: false},
+		{a: "*", b: "read:cluster", subsumes: false},
+	} {
+		if actual := permissionSubsumes(tc.a, tc.b); actual != tc.subsumes {
+			t.Errorf("permissionSubsumes(%q, %q) = %t, expected %t", tc.a, tc.b, actual, tc.subsumes)
+		}
+	}
+}
+
+func TestBreakingExemptMethod(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/breaking_exempt/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/breaking_exempt/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "Method \"test.TestService.DeleteCluster\" permissions changed from [delete:cluster] to [delete:clusters] (requires_all=true), this is a breaking change",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "service.proto",
+					StartLine:   15,
+					StartColumn: 2,
+					EndLine:     17,
+					EndColumn:   3,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestRenumberedPermissionsExtension(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fileDescriptors, err := (&checktest.ProtoFileSpec{
+		DirPaths:  []string{"testdata/renumbered_extension/current"},
+		FilePaths: []string{"service.proto"},
+	}).ToFileDescriptors(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	againstFileDescriptors, err := (&checktest.ProtoFileSpec{
+		DirPaths:  []string{"testdata/renumbered_extension/previous"},
+		FilePaths: []string{"service.proto"},
+	}).ToFileDescriptors(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := check.NewRequest(fileDescriptors, check.WithAgainstFileDescriptors(againstFileDescriptors))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := check.NewClientForSpec(Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Check(ctx, request)
+	if err == nil || !strings.Contains(err.Error(), `extension "qdrant.cloud.common.v1.permissions" has field number 50101, expected 50001`) {
+		t.Errorf("Check() error = %v, expected a renumbered extension error", err)
+	}
+}
+
+func TestProfileUnknownWithStrictOptions(t *testing.T) {
+	t.Parallel()
+
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/new_method/current"},
+				FilePaths: []string{"service.proto"},
+			},
+			AgainstFiles: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/new_method/previous"},
+				FilePaths: []string{"service.proto"},
+			},
+			Options: map[string]any{
+				pluginutil.StrictOptionsOptionKey: true,
+				pluginutil.ProfileOptionKey:       "strict",
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  permissionsBreakingRuleID,
+				Message: "unknown option \"profile\"",
+			},
+		},
+	}.Run(t)
+}
@@ -0,0 +1,2728 @@
+// Package requiredfields implements a plugin that checks that:
+// - entity-related messages (e.g: Cluster) define a known set of common fields
+// for the Qdrant Cloud API. Default values: id, name, account_id, created_at
+// Files can require extra fields for their entities with the
+// qdrant.cloud.common.v1.required_entity_fields file option, which are added
+// to the ones configured for the plugin.
+// Required fields can be satisfied by aliases, see the timestamp_field_aliases
+// option (e.g: created_at=event_time,occurred_at).
+// Timestamp fields of entity messages (e.g: created_at) aren't repeated.
+// The timestamp_convention option selects the naming of the timestamp fields:
+// qdrant (default: created_at, last_modified_at) or aip (AIP-148: create_time,
+// update_time), which swaps the required and discouraged field names.
+// The discouraged field names can be allowed altogether with the
+// disable_preferred_field_names option (e.g: during migrations).
+// The entity messages checked by QDRANT_CLOUD_REQUIRED_ENTITY_FIELDS can be
+// restricted to the ones of some services (e.g: in a module shared by several
+// teams), see the services option. The option is scoped to this rule, the
+// other rules check the entities of all of the services.
+// Entity messages don't mix snake_case and camelCase field names. Optionally,
+// camelCase field names are always reported, see the forbid_camel_case_fields
+// option.
+// Entity messages optionally don't declare more oneofs than a configured
+// maximum. Disabled by default, see the max_oneofs option.
+// Entity messages optionally don't declare more fields than a configured
+// maximum. Disabled by default, see the max_entity_fields option.
+// Entity messages optionally declare their immutable fields (see the
+// immutable_entity_fields option) before the mutable ones. Disabled by
+// default, see the immutable_fields_first option.
+// Entity messages with a deleted_at field (soft delete) also define a
+// companion field of the soft delete convention (AIP-164). The companion field
+// can be configured with the soft_delete_companion_field option. Default value:
+// expire_time
+// Fields of entity messages don't reuse a reserved name or number. Compilers
+// reject them, but descriptors can be crafted.
+// Fields of entity messages are optionally not named like verbs (e.g: process
+// rather than a noun like status). Disabled by default, see the
+// verb_field_names option.
+// Repeated fields of entity messages are plural-named (e.g: tags). Some fields
+// can be exempted, see the plural_field_exceptions option.
+// Bool fields of entity messages use affirmative naming (e.g: enabled rather
+// than is_disabled). The negative prefixes can be configured with the
+// negative_bool_prefixes option. Default values: not_, no_
+// Required fields of entity messages (e.g: id) use plain scalar types rather
+// than nullable well-known wrappers (e.g: google.protobuf.StringValue).
+// Entities optionally require an etag field for optimistic concurrency.
+// Disabled by default, see the require_etag option.
+// Id fields (e.g: account_id or cluster_id) of entity messages optionally use a
+// typed ID message instead of a string. Disabled by default, see the
+// typed_id_fields option.
+// - Request messages (e.g: ListClustersRequest) define a known set of common fields
+// for the Qdrant Cloud API. Default values: account_id
+// - Request messages reference top-level messages, rather than defining nested
+// messages.
+// - Request messages declare account_id, the scoping identifier, before the
+// id fields of the entities of the file (e.g: cluster_id). Disabled by
+// default, see the account_id_first option.
+// - The id fields of request messages (e.g: account_id and cluster_id in
+// GetClusterRequest) are marked with google.api.field_behavior = REQUIRED, so
+// generated clients enforce them. Disabled by default, see the
+// require_field_behavior_on_ids option.
+// - Lifecycle request messages (e.g: RestoreClusterRequest) define account_id
+// plus the id of the entity (e.g: cluster_id). The lifecycle prefixes can be
+// configured with the lifecycle_method_prefixes option. Default values:
+// Undelete, Restore, Archive
+// - List request messages (e.g: ListClustersRequest) optionally define an
+// order_by field (AIP-132). Disabled by default, see the require_order_by option.
+// - Create request messages (e.g: CreateClusterRequest) define a known set of
+// common fields for the Qdrant Cloud API. Default values: account_id, request_id
+// - Get request messages (e.g: GetClusterRequest) optionally identify the
+// resource by one of a set of fields declared within a oneof (e.g: name or
+// cluster_id). Disabled by default, see the request_identifier_oneof option.
+// - Request messages don't define more fields than a configured maximum.
+// Disabled by default, see the max_request_fields option.
+// - Required enum fields of request messages can't be silently zero (e.g:
+// FORMAT_UNSPECIFIED), unless they track presence or are marked with
+// google.api.field_behavior = REQUIRED. Disabled by default, see the
+// check_required_enum_presence option.
+// - Enum fields of request messages document how the unspecified value is
+// handled in a leading comment. Disabled by default, see the
+// document_request_enums option.
+// - List request messages (e.g: ListClustersRequest) filter with a single
+// filter string (AIP-160), rather than ad-hoc filter fields (e.g: status_filter).
+// Disabled by default, see the enforce_aip_filter option.
+// - Messages which aren't entities, but define all of the required entity
+// fields, are referenced by a service. Disabled by default.
+// - Files defining messages with all of the required entity fields also define
+// a service. Disabled by default.
+// - Request messages (e.g: GetClusterRequest) are used as the input of an rpc
+// method.
+// - List request messages (e.g: ListClustersRequest) pair with a response
+// message of the same name (e.g: ListClustersResponse).
+// - Every entity field is exposed by the request or response of an rpc method,
+// either embedding the entity or naming the field (e.g: cluster_id for the id
+// of Cluster in GetClusterRequest). Disabled by default.
+// - Enums setting allow_alias justify it with a leading comment.
+// - Enum fields don't use an enum with two values besides the zero one, named
+// like a boolean (e.g: ENABLED and DISABLED), which could be a bool. Disabled
+// by default.
+// - Deprecated entity messages are only referenced by deprecated methods.
+// - Deprecated fields of entity messages are reported, as a reminder to reserve
+// their number when removed. Disabled by default.
+// - Entity messages are identified by a single id field, rather than a
+// composite key of several *_id fields, as marked by the variables of their
+// google.api.resource patterns. Disabled by default.
+// - Entity messages declare a google.api.resource annotation with a type
+// matching a pattern (e.g: qdrant.cloud/Cluster). Disabled by default, see the
+// resource_type_pattern option. Default value: ^qdrant\.cloud/{entity}$
+// - The identifier field of entity messages is marked with
+// google.api.field_behavior = IDENTIFIER (AIP-203). Disabled by default, see
+// the identifier_field option. Default value: id
+// - The name field of entity messages, a human-readable display name, isn't
+// marked with google.api.field_behavior = IDENTIFIER, which is reserved to the
+// identifier field (see the identifier_field option).
+// - The hot fields of entity messages use field numbers 1-15, which take one
+// byte on the wire. Disabled by default, see the hot_entity_fields option.
+// Default values: the required entity fields
+// - List response messages (e.g: ListClustersResponse) with a total_size
+// field also define a next_page_token field.
+// - Update request messages (e.g: UpdateClusterRequest) embed the entity
+// message plus an update_mask field, instead of spreading the entity fields.
+// - The entity message embedded by update request messages doesn't expose
+// immutable fields, unless they're marked with google.api.field_behavior =
+// OUTPUT_ONLY, IMMUTABLE or IDENTIFIER. Disabled by default, see the
+// immutable_entity_fields option. Default values: id, account_id, created_at
+// - List methods (e.g: ListClusters) use the plural of the entity returned by
+// their response, rather than the singular (e.g: ListCluster).
+// - Server streaming methods don't define pagination fields (e.g: page_token)
+// in their input message, as streaming and paging are alternative strategies.
+// - Bulk methods (e.g: ImportClusters) define the fields of the bulk convention
+// of their prefix in their input message. The fields can be configured with
+// the bulk_method_fields option. Default values: Import=source,
+// Export=destination, Batch=requests
+// - Create methods (e.g: CreateCluster) return the created entity, rather than
+// just its id.
+// - Delete methods of a service consistently return either Empty, the deleted
+// entity or a response message.
+// - Methods returning a list of entities (e.g: GetClusters returning repeated
+// Cluster) are named with a List or Search prefix.
+// - Collection methods (List, Search or Get plus a plural, e.g: GetClusterList)
+// returning a list of entities of their service are named exactly List or
+// Search plus the plural of the entity (e.g: ListClusters rather than
+// ListAllClusters or GetClusterList). Other methods returning a list of
+// entities (e.g: the bulk methods, like ImportClusters) aren't checked. The
+// methods without a List or Search prefix reported by the previous check (e.g:
+// GetClusters) aren't reported again.
+// - Request messages of a service define account_id when most of the other
+// requests of the service do.
+// - account_id fields keep the default json_name (accountId) expected by REST
+// clients.
+// - Fields don't use the legacy aliases of account_id (e.g: organization_id,
+// org_id or tenant_id).
+// - Id fields (e.g: cluster_id) have the same type across all of the request
+// messages of a file (e.g: all strings, or all typed ID messages).
+//
+// The profile option selects a bundle of option defaults, which the other
+// options override:
+// - strict: enables all of the optional checks toggled by a bool option:
+// check_required_enum_presence, typed_id_fields, require_etag,
+// document_request_enums, forbid_camel_case_fields, enforce_aip_filter,
+// require_order_by, immutable_fields_first, require_field_behavior_on_ids and
+// account_id_first.
+// The optional checks configured with a value (e.g: max_oneofs or
+// verb_field_names) still need the value to be set.
+// - lenient: keeps all of the optional checks disabled.
+//
+// To use this plugin:
+//
+//	# buf.yaml
+//	version: v2
+//	lint:
+//	  use:
+//	   - STANDARD # omit if you do not want to use the rules builtin to buf
+//	   - QDRANT_CLOUD_REQUIRED_ENTITY_FIELDS
+//	   - QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS
+//	   - QDRANT_CLOUD_UNREFERENCED_ENTITIES # optional
+//	   - QDRANT_CLOUD_DEPRECATED_ENTITIES
+//	   - QDRANT_CLOUD_DEPRECATED_ENTITY_FIELDS # optional
+//	   - QDRANT_CLOUD_ENTITY_COMPOSITE_KEYS # optional
+//	   - QDRANT_CLOUD_LIST_RESPONSE_PAGINATION
+//	   - QDRANT_CLOUD_UPDATE_REQUEST_ENTITY
+//	   - QDRANT_CLOUD_CONSISTENT_ID_TYPES
+//	   - QDRANT_CLOUD_METHOD_PLURALIZATION
+//	   - QDRANT_CLOUD_SIBLING_ACCOUNT_ID
+//	   - QDRANT_CLOUD_COLLECTION_METHOD_NAMES
+//	   - QDRANT_CLOUD_COLLECTION_METHOD_ENTITY_NAMES
+//	   - QDRANT_CLOUD_ENTITY_RESOURCE_TYPE # optional
+//	   - QDRANT_CLOUD_SERVICELESS_ENTITIES # optional
+//	   - QDRANT_CLOUD_UNUSED_REQUESTS
+//	   - QDRANT_CLOUD_LIST_RESPONSE_NAMES
+//	   - QDRANT_CLOUD_UNEXPOSED_ENTITY_FIELDS # optional
+//	   - QDRANT_CLOUD_ENUM_ALLOW_ALIAS
+//	   - QDRANT_CLOUD_CREATE_RESPONSE_ENTITY
+//	   - QDRANT_CLOUD_ENTITY_IDENTIFIER_BEHAVIOR # optional
+//	   - QDRANT_CLOUD_ENTITY_NAME_IDENTIFIER
+//	   - QDRANT_CLOUD_ACCOUNT_ID_JSON_NAME
+//	   - QDRANT_CLOUD_ACCOUNT_ID_TERMINOLOGY
+//	   - QDRANT_CLOUD_DELETE_RESPONSE_CONSISTENCY
+//	   - QDRANT_CLOUD_HOT_FIELD_NUMBERS # optional
+//	   - QDRANT_CLOUD_UPDATE_IMMUTABLE_FIELDS # optional
+//	   - QDRANT_CLOUD_STREAMING_PAGINATION
+//	   - QDRANT_CLOUD_BOOLEAN_ENUMS # optional
+//	   - QDRANT_CLOUD_BULK_METHOD_FIELDS
+//	plugins:
+//	  - plugin: buf-plugin-required-fields
+//	    # Uncomment in case you need to select a profile.
+//	    # options:
+//	    #  profile: strict
+package requiredfields
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checkutil"
+	"buf.build/go/bufplugin/descriptor"
+	"buf.build/go/bufplugin/info"
+	"buf.build/go/bufplugin/option"
+	pluralize "github.com/gertd/go-pluralize"
+	googleann "google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+const (
+	requiredEntityFieldsRuleID           = "QDRANT_CLOUD_REQUIRED_ENTITY_FIELDS"
+	requiredEntityFieldsOptionKey        = "required_entity_fields"
+	requiredRequestFieldsRuleID          = "QDRANT_CLOUD_REQUIRED_REQUEST_FIELDS"
+	requiredRequestFieldsOptionKey       = "required_request_fields"
+	requiredCreateRequestFieldsOptionKey = "required_create_request_fields"
+	requestIdentifierOneofOptionKey      = "request_identifier_oneof"
+	maxRequestFieldsOptionKey            = "max_request_fields"
+	checkRequiredEnumPresenceOptionKey   = "check_required_enum_presence"
+	unreferencedEntitiesRuleID           = "QDRANT_CLOUD_UNREFERENCED_ENTITIES"
+	deprecatedEntitiesRuleID             = "QDRANT_CLOUD_DEPRECATED_ENTITIES"
+	deprecatedEntityFieldsRuleID         = "QDRANT_CLOUD_DEPRECATED_ENTITY_FIELDS"
+	entityCompositeKeysRuleID            = "QDRANT_CLOUD_ENTITY_COMPOSITE_KEYS"
+	timestampFieldAliasesOptionKey       = "timestamp_field_aliases"
+	typedIDFieldsOptionKey               = "typed_id_fields"
+	requireEtagOptionKey                 = "require_etag"
+	documentRequestEnumsOptionKey        = "document_request_enums"
+	lifecycleMethodPrefixesOptionKey     = "lifecycle_method_prefixes"
+	forbidCamelCaseFieldsOptionKey       = "forbid_camel_case_fields"
+	enforceAIPFilterOptionKey            = "enforce_aip_filter"
+	requireOrderByOptionKey              = "require_order_by"
+	maxOneofsOptionKey                   = "max_oneofs"
+	maxEntityFieldsOptionKey             = "max_entity_fields"
+	disablePreferredFieldNamesOptionKey  = "disable_preferred_field_names"
+	listResponsePaginationRuleID         = "QDRANT_CLOUD_LIST_RESPONSE_PAGINATION"
+	updateRequestEntityRuleID            = "QDRANT_CLOUD_UPDATE_REQUEST_ENTITY"
+	consistentIDTypesRuleID              = "QDRANT_CLOUD_CONSISTENT_ID_TYPES"
+	methodPluralizationRuleID            = "QDRANT_CLOUD_METHOD_PLURALIZATION"
+	siblingAccountIDRuleID               = "QDRANT_CLOUD_SIBLING_ACCOUNT_ID"
+	collectionMethodNamesRuleID          = "QDRANT_CLOUD_COLLECTION_METHOD_NAMES"
+	collectionMethodEntityNamesRuleID    = "QDRANT_CLOUD_COLLECTION_METHOD_ENTITY_NAMES"
+	entityResourceTypeRuleID             = "QDRANT_CLOUD_ENTITY_RESOURCE_TYPE"
+	servicelessEntitiesRuleID            = "QDRANT_CLOUD_SERVICELESS_ENTITIES"
+	unusedRequestsRuleID                 = "QDRANT_CLOUD_UNUSED_REQUESTS"
+	listResponseNamesRuleID              = "QDRANT_CLOUD_LIST_RESPONSE_NAMES"
+	unexposedEntityFieldsRuleID          = "QDRANT_CLOUD_UNEXPOSED_ENTITY_FIELDS"
+	enumAllowAliasRuleID                 = "QDRANT_CLOUD_ENUM_ALLOW_ALIAS"
+	createResponseEntityRuleID           = "QDRANT_CLOUD_CREATE_RESPONSE_ENTITY"
+	entityIdentifierBehaviorRuleID       = "QDRANT_CLOUD_ENTITY_IDENTIFIER_BEHAVIOR"
+	identifierFieldOptionKey             = "identifier_field"
+	entityNameIdentifierRuleID           = "QDRANT_CLOUD_ENTITY_NAME_IDENTIFIER"
+	accountIDJSONNameRuleID              = "QDRANT_CLOUD_ACCOUNT_ID_JSON_NAME"
+	accountIDTerminologyRuleID           = "QDRANT_CLOUD_ACCOUNT_ID_TERMINOLOGY"
+	servicesOptionKey                    = "services"
+	deleteResponseConsistencyRuleID      = "QDRANT_CLOUD_DELETE_RESPONSE_CONSISTENCY"
+	pluralFieldExceptionsOptionKey       = "plural_field_exceptions"
+	negativeBoolPrefixesOptionKey        = "negative_bool_prefixes"
+	hotFieldNumbersRuleID                = "QDRANT_CLOUD_HOT_FIELD_NUMBERS"
+	hotEntityFieldsOptionKey             = "hot_entity_fields"
+	updateImmutableFieldsRuleID          = "QDRANT_CLOUD_UPDATE_IMMUTABLE_FIELDS"
+	immutableEntityFieldsOptionKey       = "immutable_entity_fields"
+	immutableFieldsFirstOptionKey        = "immutable_fields_first"
+	softDeleteCompanionFieldOptionKey    = "soft_delete_companion_field"
+	requireFieldBehaviorOnIDsOptionKey   = "require_field_behavior_on_ids"
+	accountIDFirstOptionKey              = "account_id_first"
+	verbFieldNamesOptionKey              = "verb_field_names"
+	streamingPaginationRuleID            = "QDRANT_CLOUD_STREAMING_PAGINATION"
+	booleanEnumsRuleID                   = "QDRANT_CLOUD_BOOLEAN_ENUMS"
+	bulkMethodFieldsRuleID               = "QDRANT_CLOUD_BULK_METHOD_FIELDS"
+	bulkMethodFieldsOptionKey            = "bulk_method_fields"
+	resourceTypePatternOptionKey         = "resource_type_pattern"
+	timestampConventionOptionKey         = "timestamp_convention"
+
+	// entityPlaceholder is replaced by the snake_cased entity name when
+	// it's part of a configured field name (e.g: {entity}_id -> book_id).
+	entityPlaceholder = "{entity}"
+
+	cloudProviderRegionIDFieldName = "cloud_provider_region_id"
+	accountIDFieldName             = "account_id"
+	accountIDJSONName              = "accountId"
+	updateMaskFieldName            = "update_mask"
+	etagFieldName                  = "etag"
+	timestampMessageName           = "google.protobuf.Timestamp"
+	emptyMessageName               = "google.protobuf.Empty"
+	unspecifiedEnumValueSuffix     = "_UNSPECIFIED"
+	filterFieldName                = "filter"
+	orderByFieldName               = "order_by"
+	nameFieldName                  = "name"
+	deletedAtFieldName             = "deleted_at"
+
+	// maxOneByteFieldNumber is the highest field number encoded in one byte
+	// on the wire, along with the wire type.
+	maxOneByteFieldNumber = 15
+
+	// requiredEntityFieldsExtensionName is the file option declaring extra
+	// required fields for the entity messages of the file.
+	requiredEntityFieldsExtensionName = "qdrant.cloud.common.v1.required_entity_fields"
+)
+
+// FieldValidator validates a single field.
+// Returns an error message and false if validation fails.
+type FieldValidator func(field protoreflect.FieldDescriptor) *ValidationError
+
+// MessageValidator validates a message as a whole, based on the set of fields present in the message.
+// Returns an error message and false if validation fails.
+type MessageValidator func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError
+
+// ValidationError represents a linting error and includes the error message and
+// the descriptor where the linting issue was found.
+type ValidationError struct {
+	Message    string
+	Descriptor protoreflect.Descriptor
+}
+
+var (
+	requiredEntityFieldsRuleSpec = &check.RuleSpec{
+		ID:      requiredEntityFieldsRuleID,
+		Default: true,
+		Purpose: `Checks that all entity-related messages (e.g: Cluster) define a known set of fields for the Qdrant Cloud API.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkEntityFields, checkutil.WithoutImports()),
+	}
+	requiredRequestFieldsRuleSpec = &check.RuleSpec{
+		ID:      requiredRequestFieldsRuleID,
+		Default: true,
+		Purpose: `Checks that all request methods (e.g: ListClustersRequest) define a known set of fields for the Qdrant Cloud API.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMessageRuleHandler(checkRequestFields, checkutil.WithoutImports()),
+	}
+	unreferencedEntitiesRuleSpec = &check.RuleSpec{
+		ID:      unreferencedEntitiesRuleID,
+		Default: false,
+		Purpose: `Checks that messages defining all the required entity fields are referenced by a service.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkUnreferencedEntities, checkutil.WithoutImports()),
+	}
+	deprecatedEntitiesRuleSpec = &check.RuleSpec{
+		ID:      deprecatedEntitiesRuleID,
+		Default: true,
+		Purpose: `Checks that deprecated entity messages are only referenced by deprecated methods.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkDeprecatedEntities, checkutil.WithoutImports()),
+	}
+	deprecatedEntityFieldsRuleSpec = &check.RuleSpec{
+		ID:      deprecatedEntityFieldsRuleID,
+		Default: false,
+		Purpose: `Reports deprecated fields of entity messages, as a reminder to reserve their number when removed.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkDeprecatedEntityFields, checkutil.WithoutImports()),
+	}
+	entityCompositeKeysRuleSpec = &check.RuleSpec{
+		ID:      entityCompositeKeysRuleID,
+		Default: false,
+		Purpose: `Checks that entity messages are identified by a single id field rather than a composite key.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkEntityCompositeKeys, checkutil.WithoutImports()),
+	}
+	listResponsePaginationRuleSpec = &check.RuleSpec{
+		ID:      listResponsePaginationRuleID,
+		Default: true,
+		Purpose: `Checks that all list response messages (e.g: ListClustersResponse) with a total_size field also define a next_page_token field.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMessageRuleHandler(checkListResponsePagination, checkutil.WithoutImports()),
+	}
+	updateRequestEntityRuleSpec = &check.RuleSpec{
+		ID:      updateRequestEntityRuleID,
+		Default: true,
+		Purpose: `Checks that all update request messages (e.g: UpdateClusterRequest) embed the entity message plus an update_mask field.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMessageRuleHandler(checkUpdateRequestEntity, checkutil.WithoutImports()),
+	}
+	consistentIDTypesRuleSpec = &check.RuleSpec{
+		ID:      consistentIDTypesRuleID,
+		Default: true,
+		Purpose: `Checks that id fields (e.g: cluster_id) have the same type across all request messages of a file.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkConsistentIDTypes, checkutil.WithoutImports()),
+	}
+	methodPluralizationRuleSpec = &check.RuleSpec{
+		ID:      methodPluralizationRuleID,
+		Default: true,
+		Purpose: `Checks that list methods (e.g: ListClusters) use the plural of the entity returned by their response.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkMethodPluralization, checkutil.WithoutImports()),
+	}
+	siblingAccountIDRuleSpec = &check.RuleSpec{
+		ID:      siblingAccountIDRuleID,
+		Default: true,
+		Purpose: `Checks that request messages define account_id when most of the other requests of the same service do.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkSiblingAccountID, checkutil.WithoutImports()),
+	}
+	collectionMethodNamesRuleSpec = &check.RuleSpec{
+		ID:      collectionMethodNamesRuleID,
+		Default: true,
+		Purpose: `Checks that methods returning a list of entities (e.g: repeated Cluster) are named with a List or Search prefix.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkCollectionMethodNames, checkutil.WithoutImports()),
+	}
+	collectionMethodEntityNamesRuleSpec = &check.RuleSpec{
+		ID:      collectionMethodEntityNamesRuleID,
+		Default: true,
+		Purpose: `Checks that collection methods returning a list of entities are named List or Search plus the plural of the entity (e.g: ListClusters).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewServiceRuleHandler(checkCollectionMethodEntityNames, checkutil.WithoutImports()),
+	}
+	entityResourceTypeRuleSpec = &check.RuleSpec{
+		ID:      entityResourceTypeRuleID,
+		Default: false,
+		Purpose: `Checks that all entity messages (e.g: Cluster) declare a google.api.resource annotation with a type matching the resource_type_pattern option.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkEntityResourceType, checkutil.WithoutImports()),
+	}
+	servicelessEntitiesRuleSpec = &check.RuleSpec{
+		ID:      servicelessEntitiesRuleID,
+		Default: false,
+		Purpose: `Checks that files defining messages with all of the required entity fields also define a service.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkServicelessEntities, checkutil.WithoutImports()),
+	}
+	unusedRequestsRuleSpec = &check.RuleSpec{
+		ID:      unusedRequestsRuleID,
+		Default: true,
+		Purpose: `Checks that all request messages (e.g: GetClusterRequest) are used as the input of an rpc method.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkUnusedRequests, checkutil.WithoutImports()),
+	}
+	listResponseNamesRuleSpec = &check.RuleSpec{
+		ID:      listResponseNamesRuleID,
+		Default: true,
+		Purpose: `Checks that all list request messages (e.g: ListClustersRequest) pair with a response message of the same name (e.g: ListClustersResponse).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkListResponseNames, checkutil.WithoutImports()),
+	}
+	unexposedEntityFieldsRuleSpec = &check.RuleSpec{
+		ID:      unexposedEntityFieldsRuleID,
+		Default: false,
+		Purpose: `Checks that every entity field is exposed by the request or response of at least one rpc method.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkUnexposedEntityFields, checkutil.WithoutImports()),
+	}
+	enumAllowAliasRuleSpec = &check.RuleSpec{
+		ID:      enumAllowAliasRuleID,
+		Default: true,
+		Purpose: `Checks that enums setting allow_alias justify it with a leading comment.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewEnumRuleHandler(checkEnumAllowAlias, checkutil.WithoutImports()),
+	}
+	createResponseEntityRuleSpec = &check.RuleSpec{
+		ID:      createResponseEntityRuleID,
+		Default: true,
+		Purpose: `Checks that create methods (e.g: CreateCluster) return the created entity, rather than just its id.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkCreateResponseEntity, checkutil.WithoutImports()),
+	}
+	entityIdentifierBehaviorRuleSpec = &check.RuleSpec{
+		ID:      entityIdentifierBehaviorRuleID,
+		Default: false,
+		Purpose: `Checks that the identifier field of entity messages (e.g: id) is marked with google.api.field_behavior = IDENTIFIER.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkEntityIdentifierBehavior, checkutil.WithoutImports()),
+	}
+	entityNameIdentifierRuleSpec = &check.RuleSpec{
+		ID:      entityNameIdentifierRuleID,
+		Default: true,
+		Purpose: `Checks that the name field of entity messages, a display name, isn't marked with google.api.field_behavior = IDENTIFIER.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkEntityNameIdentifier, checkutil.WithoutImports()),
+	}
+	accountIDJSONNameRuleSpec = &check.RuleSpec{
+		ID:      accountIDJSONNameRuleID,
+		Default: true,
+		Purpose: `Checks that account_id fields keep the default json_name (accountId) expected by REST clients.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFieldRuleHandler(checkAccountIDJSONName, checkutil.WithoutImports()),
+	}
+	accountIDTerminologyRuleSpec = &check.RuleSpec{
+		ID:      accountIDTerminologyRuleID,
+		Default: true,
+		Purpose: `Checks that fields don't use the legacy aliases of account_id (e.g: organization_id).`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFieldRuleHandler(checkAccountIDTerminology, checkutil.WithoutImports()),
+	}
+	deleteResponseConsistencyRuleSpec = &check.RuleSpec{
+		ID:      deleteResponseConsistencyRuleID,
+		Default: true,
+		Purpose: `Checks that all delete methods of a service consistently return either Empty, the deleted entity or a response message.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkDeleteResponseConsistency, checkutil.WithoutImports()),
+	}
+	hotFieldNumbersRuleSpec = &check.RuleSpec{
+		ID:      hotFieldNumbersRuleID,
+		Default: false,
+		Purpose: `Checks that the frequently-set fields of entity messages use field numbers 1-15, which are encoded in one byte.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFileRuleHandler(checkHotFieldNumbers, checkutil.WithoutImports()),
+	}
+	updateImmutableFieldsRuleSpec = &check.RuleSpec{
+		ID:      updateImmutableFieldsRuleID,
+		Default: false,
+		Purpose: `Checks that the entity message embedded by update request messages (e.g: UpdateClusterRequest) doesn't expose settable immutable fields.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMessageRuleHandler(checkUpdateImmutableFields, checkutil.WithoutImports()),
+	}
+	streamingPaginationRuleSpec = &check.RuleSpec{
+		ID:      streamingPaginationRuleID,
+		Default: true,
+		Purpose: `Checks that server streaming methods don't define pagination fields (e.g: page_token) in their input message.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkStreamingPagination, checkutil.WithoutImports()),
+	}
+	booleanEnumsRuleSpec = &check.RuleSpec{
+		ID:      booleanEnumsRuleID,
+		Default: false,
+		Purpose: `Checks that enum fields don't use a two-value enum named like a boolean (e.g: ENABLED and DISABLED), which could be a bool.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewFieldRuleHandler(checkBooleanEnums, checkutil.WithoutImports()),
+	}
+	bulkMethodFieldsRuleSpec = &check.RuleSpec{
+		ID:      bulkMethodFieldsRuleID,
+		Default: true,
+		Purpose: `Checks that bulk methods (e.g: ImportClusters) define the fields of the bulk convention of their prefix (e.g: source) in their input message.`,
+		Type:    check.RuleTypeLint,
+		Handler: checkutil.NewMethodRuleHandler(checkBulkMethodFields, checkutil.WithoutImports()),
+	}
+	profiles = pluginutil.Profiles{
+		"strict": {
+			checkRequiredEnumPresenceOptionKey: true,
+			typedIDFieldsOptionKey:             true,
+			requireEtagOptionKey:               true,
+			documentRequestEnumsOptionKey:      true,
+			forbidCamelCaseFieldsOptionKey:     true,
+			enforceAIPFilterOptionKey:          true,
+			requireOrderByOptionKey:            true,
+			immutableFieldsFirstOptionKey:      true,
+			requireFieldBehaviorOnIDsOptionKey: true,
+			accountIDFirstOptionKey:            true,
+		},
+		"lenient": {},
+	}
+	// optionKeys are the plugin-specific option keys recognized by the plugin.
+	optionKeys = []string{
+		pluginutil.ProfileOptionKey,
+		requiredEntityFieldsOptionKey,
+		requiredRequestFieldsOptionKey,
+		requiredCreateRequestFieldsOptionKey,
+		requestIdentifierOneofOptionKey,
+		maxRequestFieldsOptionKey,
+		checkRequiredEnumPresenceOptionKey,
+		timestampFieldAliasesOptionKey,
+		typedIDFieldsOptionKey,
+		requireEtagOptionKey,
+		documentRequestEnumsOptionKey,
+		lifecycleMethodPrefixesOptionKey,
+		forbidCamelCaseFieldsOptionKey,
+		resourceTypePatternOptionKey,
+		timestampConventionOptionKey,
+		enforceAIPFilterOptionKey,
+		requireOrderByOptionKey,
+		maxOneofsOptionKey,
+		maxEntityFieldsOptionKey,
+		disablePreferredFieldNamesOptionKey,
+		identifierFieldOptionKey,
+		servicesOptionKey,
+		pluralFieldExceptionsOptionKey,
+		negativeBoolPrefixesOptionKey,
+		hotEntityFieldsOptionKey,
+		immutableEntityFieldsOptionKey,
+		immutableFieldsFirstOptionKey,
+		softDeleteCompanionFieldOptionKey,
+		requireFieldBehaviorOnIDsOptionKey,
+		accountIDFirstOptionKey,
+		verbFieldNamesOptionKey,
+		bulkMethodFieldsOptionKey,
+	}
+	// Spec is the check spec of the plugin, served by its command and run
+	// in-process by the analysis package.
+	Spec = pluginutil.WithDisableRulesEnv(pluginutil.WithRuleMetadata(pluginutil.WithStrictOptions(pluginutil.WithProfiles(&check.Spec{
+		Rules: []*check.RuleSpec{
+			requiredEntityFieldsRuleSpec,
+			requiredRequestFieldsRuleSpec,
+			unreferencedEntitiesRuleSpec,
+			deprecatedEntitiesRuleSpec,
+			deprecatedEntityFieldsRuleSpec,
+			entityCompositeKeysRuleSpec,
+			listResponsePaginationRuleSpec,
+			updateRequestEntityRuleSpec,
+			consistentIDTypesRuleSpec,
+			methodPluralizationRuleSpec,
+			siblingAccountIDRuleSpec,
+			collectionMethodNamesRuleSpec,
+			collectionMethodEntityNamesRuleSpec,
+			entityResourceTypeRuleSpec,
+			servicelessEntitiesRuleSpec,
+			unusedRequestsRuleSpec,
+			listResponseNamesRuleSpec,
+			unexposedEntityFieldsRuleSpec,
+			enumAllowAliasRuleSpec,
+			createResponseEntityRuleSpec,
+			entityIdentifierBehaviorRuleSpec,
+			entityNameIdentifierRuleSpec,
+			accountIDJSONNameRuleSpec,
+			accountIDTerminologyRuleSpec,
+			deleteResponseConsistencyRuleSpec,
+			hotFieldNumbersRuleSpec,
+			updateImmutableFieldsRuleSpec,
+			streamingPaginationRuleSpec,
+			booleanEnumsRuleSpec,
+			bulkMethodFieldsRuleSpec,
+		},
+		Info: &info.Spec{
+			Documentation: `A plugin that checks that entity-related messages define a known set of fields for the Qdrant Cloud API.`,
+			SPDXLicenseID: "",
+			LicenseURL:    "",
+		},
+	}, profiles), optionKeys...), optionKeys...))
+
+	crudMethodWithoutFullEntityPrefixes = []string{"List", "Get", "Delete"}
+	defaultLifecycleMethodPrefixes      = []string{"Undelete", "Restore", "Archive"}
+	collectionMethodPrefixes            = []string{"List", "Search"}
+	paginationFieldNames                = []string{"page_token", "page_size"}
+	defaultResourceTypePattern          = `^qdrant\.cloud/` + entityPlaceholder + `$`
+	defaultIdentifierField              = "id"
+	defaultSoftDeleteCompanionField     = "expire_time"
+	defaultNegativeBoolPrefixes         = []string{"not_", "no_"}
+	defaultImmutableEntityFields        = []string{"id", "account_id", "created_at"}
+	defaultRequiredFields               = []string{"id", "name", "account_id", "created_at"}
+	defaultRequiredRequestFields        = []string{"account_id"}
+	defaultRequiredCreateRequestFields  = []string{"account_id", "request_id"}
+	resourcePatternVariableRegexp       = regexp.MustCompile(`{([a-z][a-z0-9_]*)}`)
+	preferredEntityFieldNames           = map[string]string{
+		"updated_at":            "last_modified_at",
+		"last_updated_at":       "last_modified_at",
+		"cloud_provider":        "cloud_provider_id",
+		"cloud_provider_region": cloudProviderRegionIDFieldName,
+		"cloud_region":          cloudProviderRegionIDFieldName,
+		"cloud_region_id":       cloudProviderRegionIDFieldName,
+	}
+	// The platform standardized on account_id, these are its legacy aliases.
+	legacyAccountIDFieldNames = map[string]string{
+		"organization_id": accountIDFieldName,
+		"org_id":          accountIDFieldName,
+		"tenant_id":       accountIDFieldName,
+	}
+	// The AIP-148 convention names the timestamp fields create_time and
+	// update_time, instead of created_at and last_modified_at.
+	aipRequiredFields            = []string{"id", "name", "account_id", "create_time"}
+	aipPreferredEntityFieldNames = map[string]string{
+		"created_at":            "create_time",
+		"updated_at":            "update_time",
+		"last_updated_at":       "update_time",
+		"last_modified_at":      "update_time",
+		"cloud_provider":        "cloud_provider_id",
+		"cloud_provider_region": cloudProviderRegionIDFieldName,
+		"cloud_region":          cloudProviderRegionIDFieldName,
+		"cloud_region_id":       cloudProviderRegionIDFieldName,
+	}
+	defaultTimestampConvention = "qdrant"
+	timestampConventions       = map[string]timestampConvention{
+		"qdrant": {requiredFields: defaultRequiredFields, preferredFieldNames: preferredEntityFieldNames},
+		"aip":    {requiredFields: aipRequiredFields, preferredFieldNames: aipPreferredEntityFieldNames},
+	}
+	// wrapperScalarTypes maps the well-known wrapper messages to the scalar
+	// type they make nullable.
+	wrapperScalarTypes = map[protoreflect.FullName]string{
+		"google.protobuf.DoubleValue": "double",
+		"google.protobuf.FloatValue":  "float",
+		"google.protobuf.Int64Value":  "int64",
+		"google.protobuf.UInt64Value": "uint64",
+		"google.protobuf.Int32Value":  "int32",
+		"google.protobuf.UInt32Value": "uint32",
+		"google.protobuf.BoolValue":   "bool",
+		"google.protobuf.StringValue": "string",
+		"google.protobuf.BytesValue":  "bytes",
+	}
+	// booleanEnumValueNames are the pairs of enum value names (without the
+	// enum prefix) which make an enum a boolean in disguise.
+	booleanEnumValueNames = [][2]string{
+		{"ENABLED", "DISABLED"},
+		{"ON", "OFF"},
+		{"YES", "NO"},
+		{"TRUE", "FALSE"},
+		{"ACTIVE", "INACTIVE"},
+	}
+	// immutableFieldBehaviors mark fields which are ignored when set by clients.
+	immutableFieldBehaviors = []googleann.FieldBehavior{
+		googleann.FieldBehavior_OUTPUT_ONLY,
+		googleann.FieldBehavior_IMMUTABLE,
+		googleann.FieldBehavior_IDENTIFIER,
+	}
+	// defaultBulkMethodFields are the fields required in the input message of
+	// bulk methods, by method prefix.
+	defaultBulkMethodFields = map[string][]string{
+		"Import": {"source"},
+		"Export": {"destination"},
+		"Batch":  {"requests"},
+	}
+)
+
+// timestampConvention is a convention for naming the timestamp fields of
+// entity messages (e.g: created_at or create_time).
+type timestampConvention struct {
+	requiredFields      []string
+	preferredFieldNames map[string]string
+}
+
+// checkEntityFields validates all entity-related messages in a file descriptor.
+// It applies:
+// - Field-level validators (e.g. preferred naming).
+// - Message-level validators (e.g. required fields).
+func checkEntityFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	requiredFields, err := getFileRequiredEntityFields(request, fileDescriptor)
+	if err != nil {
+		return err
+	}
+	requireEtag, err := option.GetBoolValue(request.Options(), requireEtagOptionKey)
+	if err != nil {
+		return err
+	}
+	if requireEtag && !slices.Contains(requiredFields, etagFieldName) {
+		requiredFields = slices.Concat(requiredFields, []string{etagFieldName})
+	}
+	fieldAliases, err := getTimestampFieldAliases(request)
+	if err != nil {
+		return err
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	services, err := option.GetStringSliceValue(request.Options(), servicesOptionKey)
+	if err != nil {
+		return err
+	}
+	convention, err := getTimestampConvention(request)
+	if err != nil {
+		return err
+	}
+	typedIDFields, err := option.GetBoolValue(request.Options(), typedIDFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	forbidCamelCaseFields, err := option.GetBoolValue(request.Options(), forbidCamelCaseFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	maxOneofs, err := option.GetInt64Value(request.Options(), maxOneofsOptionKey)
+	if err != nil {
+		return err
+	}
+	maxEntityFields, err := option.GetInt64Value(request.Options(), maxEntityFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	disablePreferredFieldNames, err := option.GetBoolValue(request.Options(), disablePreferredFieldNamesOptionKey)
+	if err != nil {
+		return err
+	}
+	pluralFieldExceptions, err := option.GetStringSliceValue(request.Options(), pluralFieldExceptionsOptionKey)
+	if err != nil {
+		return err
+	}
+	negativeBoolPrefixes, err := option.GetStringSliceValue(request.Options(), negativeBoolPrefixesOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(negativeBoolPrefixes) == 0 {
+		negativeBoolPrefixes = defaultNegativeBoolPrefixes
+	}
+	verbFieldNames, err := option.GetStringSliceValue(request.Options(), verbFieldNamesOptionKey)
+	if err != nil {
+		return err
+	}
+	immutableFieldsFirst, err := option.GetBoolValue(request.Options(), immutableFieldsFirstOptionKey)
+	if err != nil {
+		return err
+	}
+	softDeleteCompanionField, err := option.GetStringValue(request.Options(), softDeleteCompanionFieldOptionKey)
+	if err != nil {
+		return err
+	}
+	if softDeleteCompanionField == "" {
+		softDeleteCompanionField = defaultSoftDeleteCompanionField
+	}
+	messageValidators := []MessageValidator{
+		companionFieldValidator("entity", deletedAtFieldName, softDeleteCompanionField),
+		reservedFieldsValidator(),
+	}
+	if maxOneofs > 0 {
+		messageValidators = append(messageValidators, maxOneofsValidator("entity", int(maxOneofs)))
+	}
+	if maxEntityFields > 0 {
+		messageValidators = append(messageValidators, maxFieldsValidator("entity", int(maxEntityFields)))
+	}
+	if immutableFieldsFirst {
+		immutableFields, err := option.GetStringSliceValue(request.Options(), immutableEntityFieldsOptionKey)
+		if err != nil {
+			return err
+		}
+		if len(immutableFields) == 0 {
+			immutableFields = defaultImmutableEntityFields
+		}
+		messageValidators = append(messageValidators, immutableFieldOrderValidator("entity", immutableFields))
+	}
+	fieldValidators := []FieldValidator{
+		pluralRepeatedFieldValidator(pluralFieldExceptions),
+		affirmativeBoolFieldValidator(negativeBoolPrefixes),
+		wrapperTypeFieldValidator(requiredFields),
+	}
+	if !disablePreferredFieldNames {
+		fieldValidators = append(fieldValidators, preferredFieldNamesValidator(convention.preferredFieldNames))
+	}
+	if forbidCamelCaseFields {
+		fieldValidators = append(fieldValidators, camelCaseFieldValidator())
+	}
+	if len(verbFieldNames) > 0 {
+		fieldValidators = append(fieldValidators, verbFieldNameValidator(verbFieldNames))
+	}
+	if typedIDFields {
+		fieldValidators = append(fieldValidators, typedIDFieldsValidator(getIDFieldNames(extractServiceEntityNames(fileDescriptor, services, lifecyclePrefixes...))))
+	}
+	for _, err := range validateEntities(fileDescriptor, requiredFields, fieldAliases, lifecyclePrefixes, services, fieldValidators, messageValidators) {
+		responseWriter.AddAnnotation(check.WithMessage(err.Message), check.WithDescriptor(err.Descriptor))
+	}
+
+	return nil
+}
+
+// validateEntities validates all entity-related messages in a file descriptor,
+// and returns the validation errors sorted by their location in the file, so
+// annotations are always added in the same order.
+// Only the entities of the given services are validated, or of all of them if
+// empty. The given field and message validators are run in addition to the
+// default ones.
+func validateEntities(fileDescriptor descriptor.FileDescriptor, requiredFields []string, fieldAliases map[string][]string, lifecyclePrefixes []string, services []string, fieldValidators []FieldValidator, messageValidators []MessageValidator) []ValidationError {
+	errors := []ValidationError{}
+	for _, entityName := range slices.Sorted(maps.Keys(extractServiceEntityNames(fileDescriptor, services, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		errors = append(errors, validateMessage(
+			msg,
+			append([]FieldValidator{repeatedTimestampValidator()}, fieldValidators...),
+			append([]MessageValidator{missingFieldsWithAliasesValidator(requiredFields, fieldAliases), mixedFieldCasingValidator()}, messageValidators...),
+		)...)
+	}
+	sortValidationErrors(errors)
+	return errors
+}
+
+// checkUnreferencedEntities flags messages which aren't inferred as entities
+// from the service methods, but define all of the required entity fields.
+// These messages probably should be entities, or are misnamed.
+func checkUnreferencedEntities(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	requiredFields, err := getRequiredEntityFields(request)
+	if err != nil {
+		return err
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	entityNames := extractEntityNames(fileDescriptor, lifecyclePrefixes...)
+	messages := fileDescriptor.ProtoreflectFileDescriptor().Messages()
+	for i := 0; i < messages.Len(); i++ {
+		msg := messages.Get(i)
+		if _, ok := entityNames[string(msg.Name())]; ok {
+			continue
+		}
+		errors := validateMessage(msg, []FieldValidator{}, []MessageValidator{missingFieldsValidator(requiredFields)})
+		if len(errors) == 0 {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("message %q looks like an entity but isn't referenced by any service", msg.Name()),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkUnexposedEntityFields flags entity fields which aren't exposed by the
+// request or response of any rpc method of the file. A message embedding the
+// entity (e.g: GetBookResponse with a Book book field) exposes all of its
+// fields, while a message referencing the entity by name (e.g: UpdateBookRequest)
+// exposes the fields it names, optionally prefixed with the entity (e.g:
+// book_id for the id of Book).
+func checkUnexposedEntityFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	var rpcMessages []protoreflect.MessageDescriptor
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := range services.Len() {
+		methods := services.Get(i).Methods()
+		for j := range methods.Len() {
+			rpcMessages = append(rpcMessages, methods.Get(j).Input(), methods.Get(j).Output())
+		}
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		exposedFields, embedded := getExposedEntityFields(entityName, rpcMessages)
+		if embedded {
+			continue
+		}
+		fields := msg.Fields()
+		for i := range fields.Len() {
+			field := fields.Get(i)
+			if _, exposed := exposedFields[string(field.Name())]; !exposed {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("entity field %q is never exposed by any RPC", fmt.Sprintf("%s.%s", entityName, field.Name())),
+					check.WithDescriptor(field),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getExposedEntityFields returns the names of the fields of the given entity
+// exposed by the given rpc messages, or whether one of them embeds the entity,
+// exposing all of its fields.
+func getExposedEntityFields(entityName string, rpcMessages []protoreflect.MessageDescriptor) (map[string]struct{}, bool) {
+	exposedFields := make(map[string]struct{})
+	entityPrefix := toSnakeCase(entityName) + "_"
+	for _, msg := range rpcMessages {
+		referencesEntity := strings.Contains(string(msg.Name()), entityName)
+		fields := msg.Fields()
+		for i := range fields.Len() {
+			field := fields.Get(i)
+			if field.Message() != nil && string(field.Message().Name()) == entityName {
+				return nil, true
+			}
+			if referencesEntity {
+				fieldName := string(field.Name())
+				exposedFields[fieldName] = struct{}{}
+				exposedFields[strings.TrimPrefix(fieldName, entityPrefix)] = struct{}{}
+			}
+		}
+	}
+	return exposedFields, false
+}
+
+// checkEntityIdentifierBehavior validates that the identifier field of entity
+// messages (AIP-203) is marked with google.api.field_behavior = IDENTIFIER.
+// Entities without the identifier field are reported by the required fields
+// checks instead.
+func checkEntityIdentifierBehavior(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	identifierField, err := option.GetStringValue(request.Options(), identifierFieldOptionKey)
+	if err != nil {
+		return err
+	}
+	if identifierField == "" {
+		identifierField = defaultIdentifierField
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		field := msg.Fields().ByName(protoreflect.Name(identifierField))
+		if field == nil {
+			continue
+		}
+		behaviors := proto.GetExtension(field.Options(), googleann.E_FieldBehavior).([]googleann.FieldBehavior)
+		if !slices.Contains(behaviors, googleann.FieldBehavior_IDENTIFIER) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("entity %q %s should be marked IDENTIFIER", entityName, identifierField),
+				check.WithDescriptor(field),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkEntityNameIdentifier validates that the name field of entity messages,
+// which is a human-readable display name, isn't marked with
+// google.api.field_behavior = IDENTIFIER in place of the identifier field.
+func checkEntityNameIdentifier(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	identifierField, err := option.GetStringValue(request.Options(), identifierFieldOptionKey)
+	if err != nil {
+		return err
+	}
+	if identifierField == "" {
+		identifierField = defaultIdentifierField
+	}
+	if identifierField == nameFieldName {
+		return nil
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		field := msg.Fields().ByName(nameFieldName)
+		if field == nil {
+			continue
+		}
+		behaviors := proto.GetExtension(field.Options(), googleann.E_FieldBehavior).([]googleann.FieldBehavior)
+		if slices.Contains(behaviors, googleann.FieldBehavior_IDENTIFIER) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("entity %q marks %q as IDENTIFIER; use %q", entityName, nameFieldName, identifierField),
+				check.WithDescriptor(field),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkAccountIDJSONName validates that account_id fields aren't customized
+// with a json_name other than the default one (accountId), which REST clients
+// send.
+func checkAccountIDJSONName(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fieldDescriptor protoreflect.FieldDescriptor) error {
+	if string(fieldDescriptor.Name()) != accountIDFieldName || fieldDescriptor.IsExtension() {
+		return nil
+	}
+	if jsonName := fieldDescriptor.JSONName(); jsonName != accountIDJSONName {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("%s field has unexpected json_name %q", accountIDFieldName, jsonName),
+			check.WithDescriptor(fieldDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// checkAccountIDTerminology validates that fields don't use the legacy aliases
+// of account_id (e.g: org_id), separately from the entity field renames so it
+// can be toggled on its own.
+func checkAccountIDTerminology(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fieldDescriptor protoreflect.FieldDescriptor) error {
+	if fieldDescriptor.IsExtension() {
+		return nil
+	}
+	if err := preferredFieldNamesValidator(legacyAccountIDFieldNames)(fieldDescriptor); err != nil {
+		responseWriter.AddAnnotation(check.WithMessage(err.Message), check.WithDescriptor(err.Descriptor))
+	}
+
+	return nil
+}
+
+// checkHotFieldNumbers validates that the hot fields of entity messages (the
+// required ones by default) use field numbers 1-15, which take one byte on the
+// wire.
+func checkHotFieldNumbers(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	hotFields, err := option.GetStringSliceValue(request.Options(), hotEntityFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(hotFields) == 0 {
+		hotFields, err = getFileRequiredEntityFields(request, fileDescriptor)
+		if err != nil {
+			return err
+		}
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		for _, fieldName := range hotFields {
+			field := msg.Fields().ByName(protoreflect.Name(fieldName))
+			if field != nil && field.Number() > maxOneByteFieldNumber {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("field %q uses number %d; keep hot fields in 1-%d", fieldName, field.Number(), maxOneByteFieldNumber),
+					check.WithDescriptor(field),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDeleteResponseConsistency validates that all of the delete methods of a
+// service return the same shape: google.protobuf.Empty, the deleted entity (e.g:
+// Book for DeleteBook) or a response message. The first delete method of the
+// service sets the expected shape.
+func checkDeleteResponseConsistency(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		var firstMethod protoreflect.MethodDescriptor
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			if !strings.HasPrefix(string(method.Name()), "Delete") {
+				continue
+			}
+			if firstMethod == nil {
+				firstMethod = method
+				continue
+			}
+			if deleteResponseShape(firstMethod) != deleteResponseShape(method) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef(
+						"%s returns %s but %s returns %s; be consistent",
+						firstMethod.Name(), firstMethod.Output().Name(), method.Name(), method.Output().Name(),
+					),
+					check.WithDescriptor(method),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteResponseShape returns the shape of the output of a delete method:
+// empty, entity or response.
+func deleteResponseShape(method protoreflect.MethodDescriptor) string {
+	switch {
+	case method.Output().FullName() == emptyMessageName:
+		return "empty"
+	case string(method.Output().Name()) == pluginutil.InferEntityFromMethodName(string(method.Name())):
+		return "entity"
+	default:
+		return "response"
+	}
+}
+
+// checkServicelessEntities flags files which don't define any service, but
+// define messages with all of the required entity fields, as entities should
+// be defined along with their services.
+func checkServicelessEntities(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	if len(fileDescriptor.FileDescriptorProto().GetService()) > 0 {
+		return nil
+	}
+	requiredFields, err := getRequiredEntityFields(request)
+	if err != nil {
+		return err
+	}
+	messages := fileDescriptor.ProtoreflectFileDescriptor().Messages()
+	for i := 0; i < messages.Len(); i++ {
+		errors := validateMessage(messages.Get(i), []FieldValidator{}, []MessageValidator{missingFieldsValidator(requiredFields)})
+		if len(errors) == 0 {
+			responseWriter.AddAnnotation(
+				check.WithMessage("file defines entity-like messages but no services"),
+				check.WithFileName(fileDescriptor.ProtoreflectFileDescriptor().Path()),
+			)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// checkUnusedRequests flags request messages which aren't the input of any rpc
+// method, as they're probably dead or misnamed. Methods of all the files of the
+// request are considered, as requests can be defined apart from the services.
+func checkUnusedRequests(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	usedRequests := make(map[protoreflect.FullName]struct{})
+	for _, file := range request.FileDescriptors() {
+		services := file.ProtoreflectFileDescriptor().Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				usedRequests[methods.Get(j).Input().FullName()] = struct{}{}
+			}
+		}
+	}
+	messages := fileDescriptor.ProtoreflectFileDescriptor().Messages()
+	for i := 0; i < messages.Len(); i++ {
+		msg := messages.Get(i)
+		if !strings.HasSuffix(string(msg.Name()), "Request") {
+			continue
+		}
+		if _, used := usedRequests[msg.FullName()]; !used {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("request message %q is not used by any RPC", msg.Name()),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkListResponseNames flags list request messages (e.g: ListBooksRequest)
+// without a response message of the same name (e.g: ListBooksResponse), either
+// defined in the linted files or returned by an rpc method.
+func checkListResponseNames(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	responses := make(map[protoreflect.FullName]struct{})
+	for _, file := range request.FileDescriptors() {
+		messages := file.ProtoreflectFileDescriptor().Messages()
+		for i := 0; i < messages.Len(); i++ {
+			responses[messages.Get(i).FullName()] = struct{}{}
+		}
+		services := file.ProtoreflectFileDescriptor().Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				responses[methods.Get(j).Output().FullName()] = struct{}{}
+			}
+		}
+	}
+	messages := fileDescriptor.ProtoreflectFileDescriptor().Messages()
+	for i := 0; i < messages.Len(); i++ {
+		msg := messages.Get(i)
+		msgName := string(msg.Name())
+		if !strings.HasPrefix(msgName, "List") || !strings.HasSuffix(msgName, "Request") {
+			continue
+		}
+		responseName := strings.TrimSuffix(msgName, "Request") + "Response"
+		if _, found := responses[msg.ParentFile().Package().Append(protoreflect.Name(responseName))]; !found {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("%s has no matching %s", msgName, responseName),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkEnumAllowAlias flags enums setting allow_alias without a leading comment
+// justifying it, as accidental aliases can mask duplicate values.
+func checkEnumAllowAlias(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, enumDescriptor protoreflect.EnumDescriptor) error {
+	if !enumDescriptor.Options().(*descriptorpb.EnumOptions).GetAllowAlias() {
+		return nil
+	}
+	comments := enumDescriptor.ParentFile().SourceLocations().ByDescriptor(enumDescriptor).LeadingComments
+	if strings.TrimSpace(comments) == "" {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("enum %q sets allow_alias without justification", enumDescriptor.Name()),
+			check.WithDescriptor(enumDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// checkBooleanEnums flags enum fields whose enum has exactly two values besides
+// the zero one, named like on/off (e.g: ENABLED_STATUS_ENABLED and
+// ENABLED_STATUS_DISABLED). It's a heuristic, so the fields are only suggested
+// for review.
+func checkBooleanEnums(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fieldDescriptor protoreflect.FieldDescriptor) error {
+	enumDescriptor := fieldDescriptor.Enum()
+	if enumDescriptor == nil {
+		return nil
+	}
+	var valueNames []string
+	values := enumDescriptor.Values()
+	for i := range values.Len() {
+		if value := values.Get(i); value.Number() != 0 {
+			valueNames = append(valueNames, string(value.Name()))
+		}
+	}
+	if len(valueNames) != 2 {
+		return nil
+	}
+	for _, pair := range booleanEnumValueNames {
+		if (isEnumValueNamed(valueNames[0], pair[0]) && isEnumValueNamed(valueNames[1], pair[1])) ||
+			(isEnumValueNamed(valueNames[0], pair[1]) && isEnumValueNamed(valueNames[1], pair[0])) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("field %q uses a two-value enum; consider a bool", fieldDescriptor.Name()),
+				check.WithDescriptor(fieldDescriptor),
+			)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// isEnumValueNamed returns true if the given enum value name is the given
+// name, either as is or prefixed (e.g: ENABLED_STATUS_ENABLED for ENABLED).
+func isEnumValueNamed(valueName string, name string) bool {
+	return valueName == name || strings.HasSuffix(valueName, "_"+name)
+}
+
+// checkDeprecatedEntities flags methods which aren't deprecated, but reference
+// a deprecated entity message.
+func checkDeprecatedEntities(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			entityName := pluginutil.InferEntityFromMethodName(string(method.Name()), lifecyclePrefixes...)
+			if entityName == "" {
+				continue
+			}
+			msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+			if msg == nil {
+				continue
+			}
+			msgDeprecated := msg.Options().(*descriptorpb.MessageOptions).GetDeprecated()
+			methodDeprecated := method.Options().(*descriptorpb.MethodOptions).GetDeprecated()
+			if msgDeprecated && !methodDeprecated {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("entity %q is deprecated but method %q is not", entityName, method.Name()),
+					check.WithDescriptor(method),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDeprecatedEntityFields reports the deprecated fields of entity messages,
+// whose number must be reserved once they're removed, so it can't be reused
+// with a different meaning. The reuse of already reserved numbers doesn't need
+// to be checked, as it's rejected by the compiler.
+func checkDeprecatedEntityFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		fields := msg.Fields()
+		for i := range fields.Len() {
+			field := fields.Get(i)
+			if !field.Options().(*descriptorpb.FieldOptions).GetDeprecated() {
+				continue
+			}
+			responseWriter.AddAnnotation(
+				check.WithMessagef("deprecated field %q (number %d): ensure number %d is reserved when removed", field.Name(), field.Number(), field.Number()),
+				check.WithDescriptor(field),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkEntityCompositeKeys flags entity messages which don't define the
+// identifier field (see the identifier_field option), but several *_id fields
+// which are variables of their google.api.resource patterns (e.g: user_id and
+// group_id in Membership, with accounts/{account}/users/{user}/groups/{group}),
+// as they are identified by a composite key. The account_id field and the ids
+// of the other entities (e.g: invite_id) identify the parents of the entity,
+// so they aren't part of its own key. Entities without resource patterns are
+// skipped, as their key can't be told apart from references.
+func checkEntityCompositeKeys(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	identifierField, err := option.GetStringValue(request.Options(), identifierFieldOptionKey)
+	if err != nil {
+		return err
+	}
+	if identifierField == "" {
+		identifierField = defaultIdentifierField
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	entityNames := extractEntityNames(fileDescriptor, lifecyclePrefixes...)
+	for _, entityName := range slices.Sorted(maps.Keys(entityNames)) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil || msg.Fields().ByName(protoreflect.Name(identifierField)) != nil {
+			continue
+		}
+		// The account and the other entities are the parents of the entity in
+		// its resource patterns, rather than part of its own key.
+		otherEntityNames := maps.Clone(entityNames)
+		delete(otherEntityNames, entityName)
+		parentIDFieldNames := getIDFieldNames(otherEntityNames)
+		resource, _ := proto.GetExtension(msg.Options(), googleann.E_Resource).(*googleann.ResourceDescriptor)
+		keyFieldNames := make(map[string]struct{})
+		for _, pattern := range resource.GetPattern() {
+			for _, match := range resourcePatternVariableRegexp.FindAllStringSubmatch(pattern, -1) {
+				fieldName := strings.TrimSuffix(match[1], "_id") + "_id"
+				if !slices.Contains(parentIDFieldNames, fieldName) {
+					keyFieldNames[fieldName] = struct{}{}
+				}
+			}
+		}
+		keyFields := 0
+		fields := msg.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			if _, found := keyFieldNames[string(fields.Get(i).Name())]; found {
+				keyFields++
+			}
+		}
+		if keyFields > 1 {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("entity %q appears to use a composite key; prefer a single id", entityName),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkEntityResourceType validates that entity messages declare a
+// google.api.resource annotation (see AIP-123), with a type matching the
+// resource_type_pattern option. The {entity} placeholder of the pattern is
+// replaced by the entity name (e.g: ^qdrant\.cloud/{entity}$ -> ^qdrant\.cloud/Book$).
+func checkEntityResourceType(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	pattern, err := option.GetStringValue(request.Options(), resourceTypePatternOptionKey)
+	if err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = defaultResourceTypePattern
+	}
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, entityName := range slices.Sorted(maps.Keys(extractEntityNames(fileDescriptor, lifecyclePrefixes...))) {
+		msg := fileDescriptor.ProtoreflectFileDescriptor().Messages().ByName(protoreflect.Name(entityName))
+		if msg == nil {
+			continue
+		}
+		if !proto.HasExtension(msg.Options(), googleann.E_Resource) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("entity %q is missing google.api.resource annotation", entityName),
+				check.WithDescriptor(msg),
+			)
+			continue
+		}
+		typePattern, err := regexp.Compile(strings.ReplaceAll(pattern, entityPlaceholder, regexp.QuoteMeta(entityName)))
+		if err != nil {
+			return fmt.Errorf("invalid %s option value %q: %w", resourceTypePatternOptionKey, pattern, err)
+		}
+		resourceType := proto.GetExtension(msg.Options(), googleann.E_Resource).(*googleann.ResourceDescriptor).GetType()
+		if !typePattern.MatchString(resourceType) {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("entity %q resource type %q doesn't match %q", entityName, resourceType, typePattern),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkRequestFields validates messages that end with "Request" and match a known
+// CRUD pattern (e.g., ListClustersRequest). It ensures these messages include required fields.
+func checkRequestFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, messageDescriptor protoreflect.MessageDescriptor) error {
+	msgName := string(messageDescriptor.Name())
+	if !strings.HasSuffix(msgName, "Request") {
+		return nil
+	}
+	var requiredFields []string
+	// For Update methods it would be useful to check for the
+	// `{entity}_id` field. We could add it later as an improvement.
+	for _, prefix := range crudMethodWithoutFullEntityPrefixes {
+		if strings.HasPrefix(msgName, prefix) {
+			requiredFields = defaultRequiredRequestFields
+		}
+	}
+	// Create requests must carry a request_id, so retries can be made
+	// idempotent.
+	if strings.HasPrefix(msgName, "Create") {
+		createRequiredFields, err := getRequiredCreateRequestFields(request)
+		if err != nil {
+			return err
+		}
+		requiredFields = createRequiredFields
+	}
+	// Lifecycle requests (e.g: RestoreBookRequest) are treated like Update
+	// requests, and must also reference the entity by its id (e.g: book_id).
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range lifecyclePrefixes {
+		if !strings.HasPrefix(msgName, prefix) {
+			continue
+		}
+		entityName := pluginutil.InferEntityFromMethodName(strings.TrimSuffix(msgName, "Request"), lifecyclePrefixes...)
+		if entityName != "" {
+			requiredFields = slices.Concat(defaultRequiredRequestFields, expandEntityPlaceholder([]string{entityPlaceholder + "_id"}, entityName))
+		}
+	}
+	// List requests can optionally be required to support ordering (AIP-132).
+	if strings.HasPrefix(msgName, "List") {
+		requireOrderBy, err := option.GetBoolValue(request.Options(), requireOrderByOptionKey)
+		if err != nil {
+			return err
+		}
+		if requireOrderBy {
+			requiredFields = slices.Concat(requiredFields, []string{orderByFieldName})
+		}
+	}
+	messageValidators := []MessageValidator{missingFieldsValidator(requiredFields), nestedMessagesValidator("request")}
+	fieldValidators := []FieldValidator{}
+	checkRequiredEnumPresence, err := option.GetBoolValue(request.Options(), checkRequiredEnumPresenceOptionKey)
+	if err != nil {
+		return err
+	}
+	if checkRequiredEnumPresence {
+		fieldValidators = append(fieldValidators, requiredEnumPresenceValidator(requiredFields))
+	}
+	documentRequestEnums, err := option.GetBoolValue(request.Options(), documentRequestEnumsOptionKey)
+	if err != nil {
+		return err
+	}
+	if documentRequestEnums {
+		fieldValidators = append(fieldValidators, enumUnspecifiedCommentValidator())
+	}
+	requireFieldBehaviorOnIDs, err := option.GetBoolValue(request.Options(), requireFieldBehaviorOnIDsOptionKey)
+	if err != nil {
+		return err
+	}
+	if requireFieldBehaviorOnIDs {
+		entityNames := make(map[string]struct{})
+		if entityName := pluginutil.InferEntityFromMethodName(strings.TrimSuffix(msgName, "Request"), lifecyclePrefixes...); entityName != "" {
+			entityNames[entityName] = struct{}{}
+		}
+		fieldValidators = append(fieldValidators, requiredFieldBehaviorValidator(getIDFieldNames(entityNames)))
+	}
+	accountIDFirst, err := option.GetBoolValue(request.Options(), accountIDFirstOptionKey)
+	if err != nil {
+		return err
+	}
+	if accountIDFirst {
+		entityNames := make(map[string]struct{})
+		for _, file := range request.FileDescriptors() {
+			if file.ProtoreflectFileDescriptor().Path() == messageDescriptor.ParentFile().Path() {
+				entityNames = extractEntityNames(file, lifecyclePrefixes...)
+			}
+		}
+		messageValidators = append(messageValidators, accountIDOrderValidator("request", getIDFieldNames(entityNames)))
+	}
+	enforceAIPFilter, err := option.GetBoolValue(request.Options(), enforceAIPFilterOptionKey)
+	if err != nil {
+		return err
+	}
+	if enforceAIPFilter && strings.HasPrefix(msgName, "List") {
+		messageValidators = append(messageValidators, aipFilterValidator())
+	}
+	maxRequestFields, err := option.GetInt64Value(request.Options(), maxRequestFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	if maxRequestFields > 0 {
+		messageValidators = append(messageValidators, maxFieldsValidator("request", int(maxRequestFields)))
+	}
+	// Get requests can optionally identify the resource by one of several
+	// fields (e.g: name or id), declared within a oneof.
+	if strings.HasPrefix(msgName, "Get") {
+		identifierFields, err := option.GetStringSliceValue(request.Options(), requestIdentifierOneofOptionKey)
+		if err != nil {
+			return err
+		}
+		if len(identifierFields) > 0 {
+			entityName := pluginutil.InferEntityFromMethodName(strings.TrimSuffix(msgName, "Request"))
+			messageValidators = append(messageValidators, oneofFieldsValidator(expandEntityPlaceholder(identifierFields, entityName)))
+		}
+	}
+	errors := validateMessage(messageDescriptor, fieldValidators, messageValidators)
+	for _, err := range errors {
+		responseWriter.AddAnnotation(check.WithMessage(err.Message), check.WithDescriptor(err.Descriptor))
+	}
+
+	return nil
+}
+
+// checkListResponsePagination validates messages that start with "List" and
+// end with "Response" (e.g., ListClustersResponse). It ensures these messages
+// have consistent paging semantics.
+func checkListResponsePagination(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, messageDescriptor protoreflect.MessageDescriptor) error {
+	msgName := string(messageDescriptor.Name())
+	if !strings.HasPrefix(msgName, "List") || !strings.HasSuffix(msgName, "Response") {
+		return nil
+	}
+	errors := validateMessage(
+		messageDescriptor, []FieldValidator{}, []MessageValidator{companionFieldValidator("response", "total_size", "next_page_token")},
+	)
+	for _, err := range errors {
+		responseWriter.AddAnnotation(check.WithMessage(err.Message), check.WithDescriptor(err.Descriptor))
+	}
+
+	return nil
+}
+
+// checkUpdateRequestEntity validates messages that start with "Update" and
+// end with "Request" (e.g., UpdateClusterRequest). It ensures these messages
+// embed the entity message plus an update_mask, rather than the entity fields.
+func checkUpdateRequestEntity(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, messageDescriptor protoreflect.MessageDescriptor) error {
+	msgName := string(messageDescriptor.Name())
+	if !strings.HasPrefix(msgName, "Update") || !strings.HasSuffix(msgName, "Request") {
+		return nil
+	}
+	entityName := pluginutil.InferEntityFromMethodName(strings.TrimSuffix(msgName, "Request"))
+	if entityName == "" {
+		return nil
+	}
+	errors := validateMessage(messageDescriptor, []FieldValidator{}, []MessageValidator{embeddedEntityValidator(entityName)})
+	for _, err := range errors {
+		responseWriter.AddAnnotation(check.WithMessage(err.Message), check.WithDescriptor(err.Descriptor))
+	}
+
+	return nil
+}
+
+// checkUpdateImmutableFields validates that the entity message embedded by
+// update request messages (e.g: a Book book field in UpdateBookRequest)
+// doesn't expose the configured immutable fields, unless their field_behavior
+// marks them as ignored when set by clients. INPUT_ONLY doesn't exempt them.
+func checkUpdateImmutableFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, messageDescriptor protoreflect.MessageDescriptor) error {
+	msgName := string(messageDescriptor.Name())
+	if !strings.HasPrefix(msgName, "Update") || !strings.HasSuffix(msgName, "Request") {
+		return nil
+	}
+	entityName := pluginutil.InferEntityFromMethodName(strings.TrimSuffix(msgName, "Request"))
+	if entityName == "" {
+		return nil
+	}
+	immutableFields, err := option.GetStringSliceValue(request.Options(), immutableEntityFieldsOptionKey)
+	if err != nil {
+		return err
+	}
+	if len(immutableFields) == 0 {
+		immutableFields = defaultImmutableEntityFields
+	}
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Message() == nil || string(field.Message().Name()) != entityName || field.IsList() || field.IsMap() {
+			continue
+		}
+		for _, fieldName := range immutableFields {
+			entityField := field.Message().Fields().ByName(protoreflect.Name(fieldName))
+			if entityField == nil {
+				continue
+			}
+			behaviors := proto.GetExtension(entityField.Options(), googleann.E_FieldBehavior).([]googleann.FieldBehavior)
+			if slices.ContainsFunc(behaviors, func(behavior googleann.FieldBehavior) bool {
+				return slices.Contains(immutableFieldBehaviors, behavior)
+			}) {
+				continue
+			}
+			responseWriter.AddAnnotation(
+				check.WithMessagef("%s exposes immutable field %q", msgName, fieldName),
+				check.WithDescriptor(field),
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkConsistentIDTypes validates that all of the id fields (e.g: book_id)
+// of the request messages in a file have the same type. The first occurrence
+// of an id field sets the expected type.
+func checkConsistentIDTypes(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	firstIDFields := make(map[string]protoreflect.FieldDescriptor)
+	messages := fileDescriptor.ProtoreflectFileDescriptor().Messages()
+	for i := 0; i < messages.Len(); i++ {
+		msg := messages.Get(i)
+		if !strings.HasSuffix(string(msg.Name()), "Request") {
+			continue
+		}
+		fields := msg.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			field := fields.Get(j)
+			fieldName := string(field.Name())
+			if !strings.HasSuffix(fieldName, "_id") {
+				continue
+			}
+			firstField, found := firstIDFields[fieldName]
+			if !found {
+				firstIDFields[fieldName] = field
+				continue
+			}
+			if fieldTypeName(firstField) != fieldTypeName(field) {
+				responseWriter.AddAnnotation(
+					check.WithMessagef(
+						"%s is %s in %s but %s in %s",
+						fieldName, fieldTypeName(firstField), firstField.Parent().Name(), fieldTypeName(field), msg.Name(),
+					),
+					check.WithDescriptor(field),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCreateResponseEntity validates that create methods (e.g: CreateBook)
+// return the created entity, either directly (e.g: Book) or embedded in their
+// response (e.g: CreateBookResponse with a Book book field).
+func checkCreateResponseEntity(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	methodName := string(methodDescriptor.Name())
+	if !strings.HasPrefix(methodName, "Create") {
+		return nil
+	}
+	entityName := pluginutil.InferEntityFromMethodName(methodName)
+	if entityName == "" {
+		return nil
+	}
+	output := methodDescriptor.Output()
+	if string(output.Name()) == entityName {
+		return nil
+	}
+	fields := output.Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if !field.IsList() && field.Message() != nil && string(field.Message().Name()) == entityName {
+			return nil
+		}
+	}
+	responseWriter.AddAnnotation(
+		check.WithMessagef("%s should return the created %s", methodName, entityName),
+		check.WithDescriptor(methodDescriptor),
+	)
+
+	return nil
+}
+
+// checkStreamingPagination validates that server streaming methods (e.g:
+// StreamBooks) don't define pagination fields in their input message, as
+// streaming and paging are alternative strategies.
+func checkStreamingPagination(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	if !methodDescriptor.IsStreamingServer() {
+		return nil
+	}
+	inputFields := methodDescriptor.Input().Fields()
+	for _, fieldName := range paginationFieldNames {
+		if inputFields.ByName(protoreflect.Name(fieldName)) != nil {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("streaming method %q should not use pagination fields", methodDescriptor.Name()),
+				check.WithDescriptor(methodDescriptor),
+			)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// checkBulkMethodFields validates that the input message of bulk methods (e.g:
+// ImportBooks) defines the fields of the bulk convention of their prefix (e.g:
+// source for Import).
+func checkBulkMethodFields(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	bulkMethodFields, err := getBulkMethodFields(request)
+	if err != nil {
+		return err
+	}
+	methodName := string(methodDescriptor.Name())
+	for _, prefix := range slices.Sorted(maps.Keys(bulkMethodFields)) {
+		if !strings.HasPrefix(methodName, prefix) || !pluginutil.EntityNameRegexp.MatchString(strings.TrimPrefix(methodName, prefix)) {
+			continue
+		}
+		inputFields := methodDescriptor.Input().Fields()
+		for _, fieldName := range bulkMethodFields[prefix] {
+			if inputFields.ByName(protoreflect.Name(fieldName)) == nil {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("%s uses the %s prefix but lacks a %s field", methodName, prefix, fieldName),
+					check.WithDescriptor(methodDescriptor),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkMethodPluralization validates that the entity component of a list method
+// (e.g: Books in ListBooks) is plural, when the response returns a list of the
+// entity (e.g: repeated Book books).
+func checkMethodPluralization(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	methodName := string(methodDescriptor.Name())
+	entityName, found := strings.CutPrefix(methodName, "List")
+	if !found || !pluginutil.EntityNameRegexp.MatchString(entityName) {
+		return nil
+	}
+	p := pluralize.NewClient()
+	if p.IsPlural(entityName) {
+		return nil
+	}
+	fields := methodDescriptor.Output().Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if field.IsList() && field.Message() != nil && string(field.Message().Name()) == entityName {
+			responseWriter.AddAnnotation(
+				check.WithMessagef("method %q uses singular but should be plural", methodName),
+				check.WithDescriptor(methodDescriptor),
+			)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// checkCollectionMethodNames validates that methods returning a list of the
+// entity inferred from their name (e.g: GetBooks returning repeated Book) are
+// named with a List or Search prefix, as other prefixes are misleading.
+func checkCollectionMethodNames(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, methodDescriptor protoreflect.MethodDescriptor) error {
+	if isMisprefixedCollectionMethod(methodDescriptor) {
+		responseWriter.AddAnnotation(
+			check.WithMessagef("method %q returns a collection but isn't named List/Search", methodDescriptor.Name()),
+			check.WithDescriptor(methodDescriptor),
+		)
+	}
+
+	return nil
+}
+
+// isMisprefixedCollectionMethod returns whether the given method returns a
+// list of the entity inferred from its name, without a List or Search prefix.
+// e.g: GetBooks returning repeated Book.
+func isMisprefixedCollectionMethod(methodDescriptor protoreflect.MethodDescriptor) bool {
+	methodName := string(methodDescriptor.Name())
+	if slices.ContainsFunc(collectionMethodPrefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+		return false
+	}
+	entityName := pluginutil.InferEntityFromMethodName(methodName)
+	if entityName == "" {
+		return false
+	}
+	fields := methodDescriptor.Output().Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if field.IsList() && field.Message() != nil && string(field.Message().Name()) == entityName {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCollectionMethodEntityNames validates that the collection methods of a
+// service returning a list of one of its entities (e.g: repeated Book) are
+// named exactly List or Search plus the plural of the entity (e.g: ListBooks),
+// as variations (e.g: ListAllBooks or GetBookList) are harder to discover.
+// Bulk methods (e.g: ImportBooks) also return lists of entities, but they
+// aren't collection methods.
+func checkCollectionMethodEntityNames(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, serviceDescriptor protoreflect.ServiceDescriptor) error {
+	lifecyclePrefixes, err := getLifecycleMethodPrefixes(request)
+	if err != nil {
+		return err
+	}
+	methods := serviceDescriptor.Methods()
+	entityNames := make(map[string]struct{})
+	for i := range methods.Len() {
+		if entityName := pluginutil.InferEntityFromMethodName(string(methods.Get(i).Name()), lifecyclePrefixes...); entityName != "" {
+			entityNames[entityName] = struct{}{}
+		}
+	}
+	bulkMethodFields, err := getBulkMethodFields(request)
+	if err != nil {
+		return err
+	}
+	// The methods reported by the collectionMethodNames rule (e.g: GetBooks)
+	// aren't reported twice when it runs.
+	collectionMethodNamesRuns := pluginutil.IsRuleRunning(request, collectionMethodNamesRuleSpec)
+	p := pluralize.NewClient()
+	for i := range methods.Len() {
+		method := methods.Get(i)
+		if collectionMethodNamesRuns && isMisprefixedCollectionMethod(method) {
+			continue
+		}
+		methodName := string(method.Name())
+		if slices.ContainsFunc(slices.Collect(maps.Keys(bulkMethodFields)), func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+			continue
+		}
+		fields := method.Output().Fields()
+		for j := range fields.Len() {
+			field := fields.Get(j)
+			if !field.IsList() || field.Message() == nil {
+				continue
+			}
+			entityName := string(field.Message().Name())
+			if _, found := entityNames[entityName]; !found {
+				continue
+			}
+			if !isCollectionMethodName(methodName, entityName) {
+				break
+			}
+			// Search methods keep their prefix, any other one should be List.
+			prefix := collectionMethodPrefixes[0]
+			for _, collectionPrefix := range collectionMethodPrefixes {
+				if strings.HasPrefix(methodName, collectionPrefix) {
+					prefix = collectionPrefix
+					break
+				}
+			}
+			expectedName := prefix + p.Plural(entityName)
+			if methodName != expectedName {
+				responseWriter.AddAnnotation(
+					check.WithMessagef("method %q should be %q", methodName, expectedName),
+					check.WithDescriptor(method),
+				)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// isCollectionMethodName returns whether the given method name is the one of a
+// collection method of the given entity: either with a List or Search prefix,
+// or with a Get prefix not followed by the singular entity name.
+// e.g: ListAllBooks, GetBooks or GetBookList, but not GetBook or ImportBooks.
+func isCollectionMethodName(methodName, entityName string) bool {
+	if slices.ContainsFunc(collectionMethodPrefixes, func(prefix string) bool { return strings.HasPrefix(methodName, prefix) }) {
+		return true
+	}
+	name, found := strings.CutPrefix(methodName, "Get")
+	return found && name != entityName
+}
+
+// checkSiblingAccountID flags request messages of a service which don't
+// define account_id, when most of the other requests of the service do, as
+// it's likely an oversight. Unlike the required request fields rule, it
+// doesn't depend on the name of the request (e.g: Get or List).
+func checkSiblingAccountID(ctx context.Context, responseWriter check.ResponseWriter, request check.Request, fileDescriptor descriptor.FileDescriptor) error {
+	services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+	for i := 0; i < services.Len(); i++ {
+		var requests []protoreflect.MessageDescriptor
+		withAccountID := 0
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			input := methods.Get(j).Input()
+			if !strings.HasSuffix(string(input.Name()), "Request") || slices.Contains(requests, input) {
+				continue
+			}
+			requests = append(requests, input)
+			if input.Fields().ByName(accountIDFieldName) != nil {
+				withAccountID++
+			}
+		}
+		if withAccountID*2 <= len(requests) {
+			continue
+		}
+		for _, msg := range requests {
+			if msg.Fields().ByName(accountIDFieldName) != nil || msg.ParentFile().Path() != fileDescriptor.ProtoreflectFileDescriptor().Path() {
+				continue
+			}
+			responseWriter.AddAnnotation(
+				check.WithMessagef("%s is missing %s that its sibling requests require", msg.Name(), accountIDFieldName),
+				check.WithDescriptor(msg),
+			)
+		}
+	}
+
+	return nil
+}
+
+// getRequiredEntityFields returns a list of required fields for a entity
+// message. It gets the values either from a plugin option or from the default
+// values of the timestamp convention.
+func getRequiredEntityFields(request check.Request) ([]string, error) {
+	requiredFieldsOptionValue, err := option.GetStringSliceValue(request.Options(), requiredEntityFieldsOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(requiredFieldsOptionValue) > 0 {
+		return requiredFieldsOptionValue, nil
+	}
+	convention, err := getTimestampConvention(request)
+	if err != nil {
+		return nil, err
+	}
+	return convention.requiredFields, nil
+}
+
+// getTimestampConvention returns the timestamp convention selected with the
+// timestamp_convention option (e.g: aip), or the default one.
+func getTimestampConvention(request check.Request) (timestampConvention, error) {
+	name, err := option.GetStringValue(request.Options(), timestampConventionOptionKey)
+	if err != nil {
+		return timestampConvention{}, err
+	}
+	if name == "" {
+		name = defaultTimestampConvention
+	}
+	convention, found := timestampConventions[name]
+	if !found {
+		return timestampConvention{}, fmt.Errorf("unknown %s option value %q, expected one of %v", timestampConventionOptionKey, name, slices.Sorted(maps.Keys(timestampConventions)))
+	}
+	return convention, nil
+}
+
+// getFileRequiredEntityFields returns a list of required fields for the entity
+// messages of a file. The fields declared in the file itself, with the
+// required_entity_fields file option, are added to the ones configured for the
+// plugin.
+func getFileRequiredEntityFields(request check.Request, fileDescriptor descriptor.FileDescriptor) ([]string, error) {
+	requiredFields, err := getRequiredEntityFields(request)
+	if err != nil {
+		return nil, err
+	}
+	fileRequiredFields, err := pluginutil.GetStringSliceExtension(request, fileDescriptor.ProtoreflectFileDescriptor().Options(), requiredEntityFieldsExtensionName)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fileRequiredFields {
+		if !slices.Contains(requiredFields, field) {
+			requiredFields = slices.Concat(requiredFields, []string{field})
+		}
+	}
+	return requiredFields, nil
+}
+
+// getTimestampFieldAliases returns the aliases which satisfy a required entity
+// field, configured as a plugin option with the format "field=alias1,alias2".
+// e.g: created_at=event_time,occurred_at.
+func getTimestampFieldAliases(request check.Request) (map[string][]string, error) {
+	optionValue, err := option.GetStringSliceValue(request.Options(), timestampFieldAliasesOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	fieldAliases := make(map[string][]string)
+	for _, value := range optionValue {
+		field, aliases, found := strings.Cut(value, "=")
+		if !found || field == "" || aliases == "" {
+			return nil, fmt.Errorf("invalid %s option value %q, expected format: field=alias1,alias2", timestampFieldAliasesOptionKey, value)
+		}
+		fieldAliases[field] = append(fieldAliases[field], strings.Split(aliases, ",")...)
+	}
+	return fieldAliases, nil
+}
+
+// getBulkMethodFields returns the fields required in the input message of bulk
+// methods, by method prefix. It gets the values either from a plugin option
+// (e.g: Import=source,format) or from the default values.
+func getBulkMethodFields(request check.Request) (map[string][]string, error) {
+	optionValue, err := option.GetStringSliceValue(request.Options(), bulkMethodFieldsOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(optionValue) == 0 {
+		return defaultBulkMethodFields, nil
+	}
+	bulkMethodFields := make(map[string][]string)
+	for _, value := range optionValue {
+		prefix, fields, found := strings.Cut(value, "=")
+		if !found || prefix == "" || fields == "" {
+			return nil, fmt.Errorf("invalid %s option value %q, expected format: prefix=field1,field2", bulkMethodFieldsOptionKey, value)
+		}
+		bulkMethodFields[prefix] = append(bulkMethodFields[prefix], strings.Split(fields, ",")...)
+	}
+	return bulkMethodFields, nil
+}
+
+// getRequiredCreateRequestFields returns a list of required fields for a
+// Create request message. It gets the values either from a plugin option or
+// from the default values.
+func getRequiredCreateRequestFields(request check.Request) ([]string, error) {
+	requiredFieldsOptionValue, err := option.GetStringSliceValue(request.Options(), requiredCreateRequestFieldsOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(requiredFieldsOptionValue) > 0 {
+		return requiredFieldsOptionValue, nil
+	}
+	return defaultRequiredCreateRequestFields, nil
+}
+
+// getLifecycleMethodPrefixes returns the prefixes of the lifecycle methods
+// (e.g: RestoreBook), which are recognized in addition to the CRUD ones. It
+// gets the values either from a plugin option or from the default values.
+func getLifecycleMethodPrefixes(request check.Request) ([]string, error) {
+	prefixesOptionValue, err := option.GetStringSliceValue(request.Options(), lifecycleMethodPrefixesOptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixesOptionValue) > 0 {
+		return prefixesOptionValue, nil
+	}
+	return defaultLifecycleMethodPrefixes, nil
+}
+
+// extractEntityNames returns a set of entity names inferred from the name of
+// the service methods, with either a CRUD or one of the given lifecycle
+// prefixes.
+// e.g: [ListBooks, GetBook] -> {Book}.
+func extractEntityNames(fileDescriptor descriptor.FileDescriptor, lifecyclePrefixes ...string) map[string]struct{} {
+	return extractServiceEntityNames(fileDescriptor, nil, lifecyclePrefixes...)
+}
+
+// extractServiceEntityNames is like extractEntityNames, but only considers the
+// methods of the given services, or of all of them if empty.
+func extractServiceEntityNames(fileDescriptor descriptor.FileDescriptor, services []string, lifecyclePrefixes ...string) map[string]struct{} {
+	entityNames := make(map[string]struct{})
+	for _, svc := range fileDescriptor.FileDescriptorProto().GetService() {
+		if len(services) > 0 && !slices.Contains(services, svc.GetName()) {
+			continue
+		}
+		for _, method := range svc.Method {
+			entityName := pluginutil.InferEntityFromMethodName(method.GetName(), lifecyclePrefixes...)
+			if entityName != "" {
+				entityNames[entityName] = struct{}{}
+			}
+		}
+	}
+	return entityNames
+}
+
+// getIDFieldNames returns the names of the fields which reference the given
+// entities by id, including the account.
+// e.g: {Book, BookCategory} -> [account_id book_category_id book_id].
+func getIDFieldNames(entityNames map[string]struct{}) []string {
+	idFieldNames := []string{"account_id"}
+	for _, entityName := range slices.Sorted(maps.Keys(entityNames)) {
+		idFieldNames = append(idFieldNames, toSnakeCase(entityName)+"_id")
+	}
+	return idFieldNames
+}
+
+// fieldTypeName returns a human readable name of the type of a field.
+// e.g: string, BookId message or BookFormat enum.
+func fieldTypeName(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fmt.Sprintf("%s message", field.Message().Name())
+	case protoreflect.EnumKind:
+		return fmt.Sprintf("%s enum", field.Enum().Name())
+	}
+	return field.Kind().String()
+}
+
+// expandEntityPlaceholder replaces the entity placeholder in the given field
+// names with the snake_cased entity name.
+// e.g: [name {entity}_id], BookCategory -> [name book_category_id].
+func expandEntityPlaceholder(fieldNames []string, entityName string) []string {
+	expanded := make([]string, 0, len(fieldNames))
+	for _, fieldName := range fieldNames {
+		expanded = append(expanded, strings.ReplaceAll(fieldName, entityPlaceholder, toSnakeCase(entityName)))
+	}
+	return expanded
+}
+
+// toSnakeCase converts a CamelCase name into snake_case.
+// e.g: BookCategory -> book_category.
+func toSnakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// sortValidationErrors sorts the given validation errors by the location of
+// their descriptors, and then by message.
+func sortValidationErrors(errors []ValidationError) {
+	slices.SortStableFunc(errors, func(a, b ValidationError) int {
+		aLocation := a.Descriptor.ParentFile().SourceLocations().ByDescriptor(a.Descriptor)
+		bLocation := b.Descriptor.ParentFile().SourceLocations().ByDescriptor(b.Descriptor)
+		return cmp.Or(
+			cmp.Compare(aLocation.StartLine, bLocation.StartLine),
+			cmp.Compare(aLocation.StartColumn, bLocation.StartColumn),
+			cmp.Compare(aLocation.EndLine, bLocation.EndLine),
+			cmp.Compare(aLocation.EndColumn, bLocation.EndColumn),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+}
+
+// validateMessage runs a set of field-level and message-level validators
+// against a protobuf message descriptor.
+//
+// Field-level validators are executed for each individual field in the message,
+// allowing checks like discouraged field names or naming conventions.
+//
+// Message-level validators are run once per message, and have access to the
+// full set of field names, enabling checks like required field presence.
+func validateMessage(msg protoreflect.MessageDescriptor, fieldValidators []FieldValidator, messageValidators []MessageValidator) []ValidationError {
+	// missingFields := []string{}
+	existingFields := make(map[string]bool)
+	fields := msg.Fields()
+	errors := []ValidationError{}
+
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		fieldName := string(field.Name())
+		existingFields[string(fieldName)] = true
+
+		for _, validator := range fieldValidators {
+			if err := validator(field); err != nil {
+				errors = append(errors, *err)
+			}
+		}
+	}
+
+	for _, validator := range messageValidators {
+		if err := validator(msg, existingFields); err != nil {
+			errors = append(errors, *err)
+		}
+	}
+
+	return errors
+}
+
+// preferredFieldNamesValidator returns a FieldValidator that checks
+// if a given field name is discouraged and suggests the preferred one.
+func preferredFieldNamesValidator(preferredFieldNames map[string]string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		fieldName := string(field.Name())
+		if suggestion, ok := preferredFieldNames[fieldName]; ok && suggestion != fieldName {
+			return &ValidationError{
+				Message:    fmt.Sprintf("field %q is discouraged, use %q instead", fieldName, suggestion),
+				Descriptor: field,
+			}
+		}
+		return nil
+	}
+}
+
+// repeatedTimestampValidator returns a FieldValidator that checks if a given
+// field is a repeated timestamp, which is most likely a modeling error.
+func repeatedTimestampValidator() FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if field.IsList() && field.Message() != nil && field.Message().FullName() == timestampMessageName {
+			return &ValidationError{
+				Message:    fmt.Sprintf("field %q should not be repeated", field.Name()),
+				Descriptor: field,
+			}
+		}
+		return nil
+	}
+}
+
+// pluralRepeatedFieldValidator returns a FieldValidator that ensures repeated
+// fields are plural-named (e.g: tags rather than tag). Map fields and the given
+// exceptions aren't checked, nor timestamps, which shouldn't be repeated at all
+// (see repeatedTimestampValidator).
+func pluralRepeatedFieldValidator(exceptions []string) FieldValidator {
+	p := pluralize.NewClient()
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		fieldName := string(field.Name())
+		if !field.IsList() || slices.Contains(exceptions, fieldName) || p.IsPlural(fieldName) {
+			return nil
+		}
+		if field.Message() != nil && field.Message().FullName() == timestampMessageName {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("repeated field %q should be plural %q", fieldName, p.Plural(fieldName)),
+			Descriptor: field,
+		}
+	}
+}
+
+// affirmativeBoolFieldValidator returns a FieldValidator that ensures bool
+// fields use affirmative naming, as negative names (e.g: not_ready or
+// is_disabled) lead to double negatives. Names starting with one of the given
+// prefixes, or containing "disabled", are considered negative.
+func affirmativeBoolFieldValidator(negativePrefixes []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if field.Kind() != protoreflect.BoolKind {
+			return nil
+		}
+		fieldName := string(field.Name())
+		var suggestion string
+		for _, prefix := range negativePrefixes {
+			if strings.HasPrefix(fieldName, prefix) {
+				suggestion = strings.TrimPrefix(fieldName, prefix)
+				break
+			}
+		}
+		if suggestion == "" && strings.Contains(fieldName, "disabled") {
+			suggestion = strings.ReplaceAll(strings.TrimPrefix(fieldName, "is_"), "disabled", "enabled")
+		}
+		if suggestion == "" {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("bool field %q should use affirmative naming like %q", fieldName, suggestion),
+			Descriptor: field,
+		}
+	}
+}
+
+// verbFieldNameValidator returns a FieldValidator that ensures fields aren't
+// named like one of the given verbs (e.g: process), as entity fields should be
+// nouns (e.g: status).
+func verbFieldNameValidator(verbs []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		fieldName := string(field.Name())
+		if slices.Contains(verbs, fieldName) {
+			return &ValidationError{
+				Message:    fmt.Sprintf("field %q looks like a verb; entity fields should be nouns", fieldName),
+				Descriptor: field,
+			}
+		}
+		return nil
+	}
+}
+
+// wrapperTypeFieldValidator returns a FieldValidator that ensures the given
+// required fields aren't typed as a well-known wrapper (e.g: StringValue), as
+// they're always set and don't need to be nullable.
+func wrapperTypeFieldValidator(requiredFields []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if field.Message() == nil || field.IsList() || !slices.Contains(requiredFields, string(field.Name())) {
+			return nil
+		}
+		scalarType, found := wrapperScalarTypes[field.Message().FullName()]
+		if !found {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("field %q uses %s; use a plain %s", field.Name(), field.Message().Name(), scalarType),
+			Descriptor: field,
+		}
+	}
+}
+
+// typedIDFieldsValidator returns a FieldValidator that checks if a given id
+// field uses a typed ID message (e.g: AccountId) instead of a scalar type.
+func typedIDFieldsValidator(idFieldNames []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		fieldName := string(field.Name())
+		if slices.Contains(idFieldNames, fieldName) && field.Kind() != protoreflect.MessageKind {
+			return &ValidationError{
+				Message:    fmt.Sprintf("field %q should use a typed ID message", fieldName),
+				Descriptor: field,
+			}
+		}
+		return nil
+	}
+}
+
+// requiredFieldBehaviorValidator returns a FieldValidator that ensures the
+// given fields (e.g: account_id) are marked with google.api.field_behavior =
+// REQUIRED.
+func requiredFieldBehaviorValidator(requiredFields []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if !slices.Contains(requiredFields, string(field.Name())) {
+			return nil
+		}
+		behaviors := proto.GetExtension(field.Options(), googleann.E_FieldBehavior).([]googleann.FieldBehavior)
+		if !slices.Contains(behaviors, googleann.FieldBehavior_REQUIRED) {
+			return &ValidationError{
+				Message:    fmt.Sprintf("request field %q should be marked REQUIRED", field.Name()),
+				Descriptor: field,
+			}
+		}
+		return nil
+	}
+}
+
+// requiredEnumPresenceValidator returns a FieldValidator that checks if a
+// required enum field can be silently zero, i.e. its zero value is
+// _UNSPECIFIED, the field doesn't track presence and it isn't marked with
+// google.api.field_behavior = REQUIRED.
+func requiredEnumPresenceValidator(requiredFields []string) FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		fieldName := string(field.Name())
+		if field.Kind() != protoreflect.EnumKind || field.HasPresence() || !slices.Contains(requiredFields, fieldName) {
+			return nil
+		}
+		behaviors := proto.GetExtension(field.Options(), googleann.E_FieldBehavior).([]googleann.FieldBehavior)
+		if slices.Contains(behaviors, googleann.FieldBehavior_REQUIRED) {
+			return nil
+		}
+		zeroValue := field.Enum().Values().ByNumber(0)
+		if zeroValue == nil || !strings.HasSuffix(string(zeroValue.Name()), unspecifiedEnumValueSuffix) {
+			return nil
+		}
+		return &ValidationError{
+			Message: fmt.Sprintf(
+				"required enum field %q can be silently %s, consider declaring it in a oneof or using google.api.field_behavior = REQUIRED",
+				fieldName, zeroValue.Name(),
+			),
+			Descriptor: field,
+		}
+	}
+}
+
+// enumUnspecifiedCommentValidator returns a FieldValidator that checks that
+// enum fields have a leading comment documenting how the unspecified value
+// (e.g: FORMAT_UNSPECIFIED) is handled (e.g: rejected or defaulted).
+func enumUnspecifiedCommentValidator() FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if field.Kind() != protoreflect.EnumKind {
+			return nil
+		}
+		comments := field.ParentFile().SourceLocations().ByDescriptor(field).LeadingComments
+		if strings.Contains(strings.ToLower(comments), "unspecified") {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("enum field %q in request should document unspecified handling", field.Name()),
+			Descriptor: field,
+		}
+	}
+}
+
+// camelCaseFieldValidator returns a FieldValidator that checks that fields
+// aren't named in camelCase, as the proto style guide prefers snake_case.
+func camelCaseFieldValidator() FieldValidator {
+	return func(field protoreflect.FieldDescriptor) *ValidationError {
+		if !isCamelCase(string(field.Name())) {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("field %q should be snake_case", field.Name()),
+			Descriptor: field,
+		}
+	}
+}
+
+// mixedFieldCasingValidator returns a MessageValidator that checks that a
+// message doesn't mix snake_case (e.g: created_at) and camelCase (e.g:
+// createdAt) field names. Single word names (e.g: name) fit both.
+func mixedFieldCasingValidator() MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		hasSnakeCase, hasCamelCase := false, false
+		for fieldName := range messageFields {
+			if isCamelCase(fieldName) {
+				hasCamelCase = true
+			} else if strings.Contains(fieldName, "_") {
+				hasSnakeCase = true
+			}
+		}
+		if !hasSnakeCase || !hasCamelCase {
+			return nil
+		}
+		return &ValidationError{
+			Message:    fmt.Sprintf("entity %q mixes snake_case and camelCase field names", message.Name()),
+			Descriptor: message,
+		}
+	}
+}
+
+// isCamelCase checks if the given field name is in camelCase (e.g: createdAt).
+func isCamelCase(fieldName string) bool {
+	return strings.ContainsFunc(fieldName, unicode.IsUpper)
+}
+
+// missingFieldsValidator returns a MessageValidator that ensures a message
+// contains all of the specified required fields.
+func missingFieldsValidator(requiredFields []string) MessageValidator {
+	return missingFieldsWithAliasesValidator(requiredFields, nil)
+}
+
+// missingFieldsWithAliasesValidator returns a MessageValidator that ensures a
+// message contains all of the specified required fields, or any of their
+// aliases (e.g: event_time instead of created_at).
+func missingFieldsWithAliasesValidator(requiredFields []string, fieldAliases map[string][]string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		messageName := string(message.Name())
+		missingFields := []string{}
+		for _, requiredField := range requiredFields {
+			if messageFields[requiredField] {
+				continue
+			}
+			if !slices.ContainsFunc(fieldAliases[requiredField], func(alias string) bool { return messageFields[alias] }) {
+				missingFields = append(missingFields, requiredField)
+			}
+		}
+		if len(missingFields) > 0 {
+			return &ValidationError{
+				Message:    fmt.Sprintf("message %q is missing required fields: %v", messageName, missingFields),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// oneofFieldsValidator returns a MessageValidator that ensures a message
+// contains at least one of the given fields, and that all of the present
+// fields are declared within the same oneof.
+func oneofFieldsValidator(fieldGroup []string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		var oneof protoreflect.OneofDescriptor
+		found := false
+		valid := true
+		for _, fieldName := range fieldGroup {
+			if !messageFields[fieldName] {
+				continue
+			}
+			found = true
+			fieldOneof := message.Fields().ByName(protoreflect.Name(fieldName)).ContainingOneof()
+			if fieldOneof == nil || fieldOneof.IsSynthetic() || (oneof != nil && oneof.FullName() != fieldOneof.FullName()) {
+				valid = false
+				break
+			}
+			oneof = fieldOneof
+		}
+		if !found || !valid {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s must identify the resource by one of %v in a oneof", message.Name(), fieldGroup),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// maxFieldsValidator returns a MessageValidator that ensures a message
+// doesn't define more than the given number of fields.
+func maxFieldsValidator(messageKind string, maxFields int) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		if len(messageFields) > maxFields {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s %q has %d fields, exceeding the max of %d", messageKind, message.Name(), len(messageFields), maxFields),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// nestedMessagesValidator returns a MessageValidator that ensures a message
+// doesn't define nested messages, but references top-level ones instead. The
+// entries of map fields aren't considered.
+func nestedMessagesValidator(messageKind string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		for i := 0; i < message.Messages().Len(); i++ {
+			if !message.Messages().Get(i).IsMapEntry() {
+				return &ValidationError{
+					Message:    fmt.Sprintf("%s %q should not define nested messages", messageKind, message.Name()),
+					Descriptor: message,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// accountIDOrderValidator returns a MessageValidator that ensures a message
+// declares account_id before the given id fields (e.g: book_id), as it scopes
+// them.
+func accountIDOrderValidator(messageKind string, idFieldNames []string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		accountIDField := message.Fields().ByName(accountIDFieldName)
+		if accountIDField == nil {
+			return nil
+		}
+		for i := 0; i < accountIDField.Index(); i++ {
+			fieldName := string(message.Fields().Get(i).Name())
+			if slices.Contains(idFieldNames, fieldName) {
+				return &ValidationError{
+					Message:    fmt.Sprintf("%s %q declares %s before %s", messageKind, message.Name(), fieldName, accountIDFieldName),
+					Descriptor: accountIDField,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// immutableFieldOrderValidator returns a MessageValidator that ensures the
+// given immutable fields (e.g: created_at) are declared before the mutable
+// ones, so they're grouped at the top of the message.
+func immutableFieldOrderValidator(messageKind string, immutableFields []string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		var mutableField protoreflect.FieldDescriptor
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if !slices.Contains(immutableFields, string(field.Name())) {
+				if mutableField == nil {
+					mutableField = field
+				}
+				continue
+			}
+			if mutableField != nil {
+				return &ValidationError{
+					Message:    fmt.Sprintf("%s %q declares mutable %q before immutable %q", messageKind, message.Name(), mutableField.Name(), field.Name()),
+					Descriptor: field,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// maxOneofsValidator returns a MessageValidator that ensures a message doesn't
+// declare more than the given number of oneofs. The synthetic oneofs of proto3
+// optional fields aren't counted.
+func maxOneofsValidator(messageKind string, maxOneofs int) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		oneofs := 0
+		for i := 0; i < message.Oneofs().Len(); i++ {
+			if !message.Oneofs().Get(i).IsSynthetic() {
+				oneofs++
+			}
+		}
+		if oneofs > maxOneofs {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s %q declares %d oneofs, exceeding the max of %d", messageKind, message.Name(), oneofs, maxOneofs),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// aipFilterValidator returns a MessageValidator that ensures a List request
+// filters with a single filter string (AIP-160), rather than ad-hoc filter
+// fields. This is a heuristic: a *_filter scalar field, or several optional
+// scalar fields, are considered ad-hoc filter fields.
+func aipFilterValidator() MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		filterFields, optionalFields := 0, 0
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if field.Message() != nil || field.IsList() || field.IsMap() {
+				continue
+			}
+			if strings.HasSuffix(string(field.Name()), "_filter") {
+				filterFields++
+			} else if field.HasOptionalKeyword() {
+				optionalFields++
+			}
+		}
+		if filterFields > 0 || optionalFields > 1 {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s uses ad-hoc filter fields; prefer a single %q string", message.Name(), filterFieldName),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// companionFieldValidator returns a MessageValidator that ensures a message
+// containing the given field also contains its companion field.
+func companionFieldValidator(messageKind string, field string, companionField string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		if messageFields[field] && !messageFields[companionField] {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s %q has %s but no %s", messageKind, message.Name(), field, companionField),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
+
+// reservedFieldsValidator returns a MessageValidator that ensures a message
+// doesn't declare fields with a reserved name or number. Compilers reject
+// them, but a crafted descriptor could still declare them.
+func reservedFieldsValidator() MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if message.ReservedNames().Has(field.Name()) {
+				return &ValidationError{
+					Message:    fmt.Sprintf("field %q reuses a reserved name", field.Name()),
+					Descriptor: field,
+				}
+			}
+			if message.ReservedRanges().Has(field.Number()) {
+				return &ValidationError{
+					Message:    fmt.Sprintf("field %q reuses reserved number %d", field.Name(), field.Number()),
+					Descriptor: field,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// embeddedEntityValidator returns a MessageValidator that ensures a message
+// contains exactly one field whose type is the given entity message, plus an
+// update_mask field.
+func embeddedEntityValidator(entityName string) MessageValidator {
+	return func(message protoreflect.MessageDescriptor, messageFields map[string]bool) *ValidationError {
+		entityFields := 0
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if field.Message() != nil && string(field.Message().Name()) == entityName && !field.IsList() && !field.IsMap() {
+				entityFields++
+			}
+		}
+		if entityFields != 1 || !messageFields[updateMaskFieldName] {
+			return &ValidationError{
+				Message:    fmt.Sprintf("%s should embed a %s plus %s", message.Name(), entityName, updateMaskFieldName),
+				Descriptor: message,
+			}
+		}
+		return nil
+	}
+}
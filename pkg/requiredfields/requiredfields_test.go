@@ -0,0 +1,3285 @@
+package requiredfields
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/bufplugin/check/checktest"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/qdrant/qdrant-cloud-buf-plugins/internal/pluginutil"
+)
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+	checktest.SpecTest(t, Spec)
+}
+
+// assertAnnotationCounts runs the given check test and asserts the number of
+// annotations reported by each rule, to catch rules over or under-reporting
+// even when the expected annotations are updated along with them.
+func assertAnnotationCounts(t *testing.T, checkTest checktest.CheckTest, expectedCounts map[string]int) {
+	t.Helper()
+
+	checkTest.Run(t)
+
+	ctx := context.Background()
+	request, err := checkTest.Request.ToRequest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := check.NewClientForSpec(checkTest.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Check(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, annotation := range response.Annotations() {
+		counts[annotation.RuleID()]++
+	}
+	if !maps.Equal(counts, expectedCounts) {
+		t.Errorf("annotation counts = %v, expected %v", counts, expectedCounts)
+	}
+}
+
+func TestSimpleSuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestSimpleFailureWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				requiredEntityFieldsOptionKey: []string{"category"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   50,
+					StartColumn: 4,
+					EndLine:     50,
+					EndColumn:   45,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"BookCategory\" is missing required fields: [category]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   53,
+					StartColumn: 0,
+					EndLine:     60,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"last_updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   59,
+					StartColumn: 4,
+					EndLine:     59,
+					EndColumn:   50,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 3,
+	})
+}
+
+func TestDisablePreferredFieldNames(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				disablePreferredFieldNamesOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [id account_id created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   42,
+					StartColumn: 0,
+					EndLine:     51,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"BookCategory\" is missing required fields: [name]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   53,
+					StartColumn: 0,
+					EndLine:     60,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestServicesOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/services"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				servicesOptionKey: []string{"BookService"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   32,
+					StartColumn: 0,
+					EndLine:     36,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestSimpleFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [id account_id created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   42,
+					StartColumn: 0,
+					EndLine:     51,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   50,
+					StartColumn: 4,
+					EndLine:     50,
+					EndColumn:   45,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"BookCategory\" is missing required fields: [name]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   53,
+					StartColumn: 0,
+					EndLine:     60,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"last_updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   59,
+					StartColumn: 4,
+					EndLine:     59,
+					EndColumn:   50,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"ListBooksRequest\" is missing required fields: [account_id]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   17,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"GetBookRequest\" is missing required fields: [account_id]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 0,
+					EndLine:     28,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"CreateBookCategoryRequest\" is missing required fields: [account_id request_id]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 0,
+					EndLine:     36,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID:  4,
+		requiredRequestFieldsRuleID: 3,
+	})
+}
+
+func TestCreateRequestFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/create_request_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"CreateBookRequest\" is missing required fields: [request_id]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   11,
+					StartColumn: 0,
+					EndLine:     15,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestCreateRequestFailureWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/create_request_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				requiredCreateRequestFieldsOptionKey: []string{"account_id"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestRequestIdentifierOneofSuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/oneof_identifier_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				requestIdentifierOneofOptionKey: []string{"name", "{entity}_id"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestRequestIdentifierOneofFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/oneof_identifier_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				requestIdentifierOneofOptionKey: []string{"name", "{entity}_id"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "GetBookRequest must identify the resource by one of [name book_id] in a oneof",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "GetBookCategoryRequest must identify the resource by one of [name book_category_id] in a oneof",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 0,
+					EndLine:     28,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 2,
+	})
+}
+
+func TestAIPFilterFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/aip_filter"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				enforceAIPFilterOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "ListBooksRequest uses ad-hoc filter fields; prefer a single \"filter\" string",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   16,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "ListAuthorsRequest uses ad-hoc filter fields; prefer a single \"filter\" string",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   26,
+					StartColumn: 0,
+					EndLine:     30,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 2,
+	})
+}
+
+func TestRequireOrderByFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/require_order_by"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				requireOrderByOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"ListBooksRequest\" is missing required fields: [order_by]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 0,
+					EndLine:     16,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestMaxRequestFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/max_request_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				maxRequestFieldsOptionKey: int64(3),
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request \"UpdateBookRequest\" has 4 fields, exceeding the max of 3",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   11,
+					StartColumn: 0,
+					EndLine:     16,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  updateRequestEntityRuleID,
+				Message: "UpdateBookRequest should embed a Book plus update_mask",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   11,
+					StartColumn: 0,
+					EndLine:     16,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+		updateRequestEntityRuleID:   1,
+	})
+}
+
+func TestUnreferencedEntitiesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/unreferenced_entities"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{unreferencedEntitiesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  unreferencedEntitiesRuleID,
+				Message: "message \"Author\" looks like an entity but isn't referenced by any service",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   28,
+					StartColumn: 0,
+					EndLine:     33,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		unreferencedEntitiesRuleID: 1,
+	})
+}
+
+func TestDeprecatedEntitiesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/deprecated_entities"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  deprecatedEntitiesRuleID,
+				Message: "entity \"Book\" is deprecated but method \"GetBook\" is not",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   11,
+					StartColumn: 4,
+					EndLine:     13,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		deprecatedEntitiesRuleID: 1,
+	})
+}
+
+func TestDeprecatedEntityFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/deprecated_entity_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{deprecatedEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  deprecatedEntityFieldsRuleID,
+				Message: "deprecated field \"legacy_flag\" (number 7): ensure number 7 is reserved when removed",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   27,
+					StartColumn: 4,
+					EndLine:     27,
+					EndColumn:   45,
+				},
+			},
+		},
+	}, map[string]int{
+		deprecatedEntityFieldsRuleID: 1,
+	})
+}
+
+func TestTimestampFieldAliasesSuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/timestamp_field_aliases"},
+				FilePaths: []string{"simple.proto"},
+			},
+			Options: map[string]any{
+				timestampFieldAliasesOptionKey: []string{"created_at=event_time,occurred_at"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestTimestampFieldAliasesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/timestamp_field_aliases"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"AuditEvent\" is missing required fields: [created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   19,
+					StartColumn: 0,
+					EndLine:     25,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestListResponsePaginationFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/list_response_pagination"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  listResponsePaginationRuleID,
+				Message: "response \"ListBooksResponse\" has total_size but no next_page_token",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   15,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		listResponsePaginationRuleID: 1,
+	})
+}
+
+func TestUpdateRequestEntitySuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/update_request_entity_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{updateRequestEntityRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestUpdateRequestEntityFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/update_request_entity_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{updateRequestEntityRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  updateRequestEntityRuleID,
+				Message: "UpdateBookRequest should embed a Book plus update_mask",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   13,
+					StartColumn: 0,
+					EndLine:     17,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  updateRequestEntityRuleID,
+				Message: "UpdateAuthorRequest should embed a Author plus update_mask",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   23,
+					StartColumn: 0,
+					EndLine:     25,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		updateRequestEntityRuleID: 2,
+	})
+}
+
+func TestRequiredEnumPresenceFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_enum_presence"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				requiredCreateRequestFieldsOptionKey: []string{"account_id", "request_id", "format"},
+				checkRequiredEnumPresenceOptionKey:   true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "required enum field \"format\" can be silently BOOK_FORMAT_UNSPECIFIED, consider declaring it in a oneof or using google.api.field_behavior = REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   26,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestRequiredEnumPresenceWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_enum_presence"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				requiredCreateRequestFieldsOptionKey: []string{"account_id", "request_id", "format"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestRequiredEnumPresenceFieldBehavior(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_enum_presence_field_behavior"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				requiredCreateRequestFieldsOptionKey: []string{"account_id", "request_id", "format"},
+				checkRequiredEnumPresenceOptionKey:   true,
+			},
+		},
+		Spec: Spec,
+		// No expected annotations - the format field is already marked REQUIRED
+	}, nil)
+}
+
+func TestValidateEntitiesOrdering(t *testing.T) {
+	t.Parallel()
+
+	checkTest := checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [id account_id created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   42,
+					StartColumn: 0,
+					EndLine:     51,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   50,
+					StartColumn: 4,
+					EndLine:     50,
+					EndColumn:   45,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"BookCategory\" is missing required fields: [name]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   53,
+					StartColumn: 0,
+					EndLine:     60,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"last_updated_at\" is discouraged, use \"last_modified_at\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   59,
+					StartColumn: 4,
+					EndLine:     59,
+					EndColumn:   50,
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	client, err := check.NewClientForSpec(Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runs [][]string
+	for range 2 {
+		checkTest.Run(t)
+		request, err := checkTest.Request.ToRequest(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := client.Check(ctx, request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var annotations []string
+		for _, annotation := range response.Annotations() {
+			fileLocation := annotation.FileLocation()
+			annotations = append(annotations, fmt.Sprintf("%s %d:%d %s", annotation.RuleID(), fileLocation.StartLine(), fileLocation.StartColumn(), annotation.Message()))
+		}
+		runs = append(runs, annotations)
+	}
+	if !slices.Equal(runs[0], runs[1]) {
+		t.Errorf("annotations changed between runs: %v != %v", runs[0], runs[1])
+	}
+}
+
+func TestTypedIDFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/typed_id_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				typedIDFieldsOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"author_id\" should use a typed ID message",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   41,
+					StartColumn: 4,
+					EndLine:     41,
+					EndColumn:   25,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestTypedIDFieldsWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/typed_id_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestConsistentIDTypesSuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/consistent_id_types_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{consistentIDTypesRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestConsistentIDTypesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/consistent_id_types_failure"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{consistentIDTypesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  consistentIDTypesRuleID,
+				Message: "book_id is string in GetBookRequest but BookId message in DeleteBookRequest",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   24,
+					StartColumn: 4,
+					EndLine:     24,
+					EndColumn:   23,
+				},
+			},
+		},
+	}, map[string]int{
+		consistentIDTypesRuleID: 1,
+	})
+}
+
+func TestMaxOneofsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/max_oneofs"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				maxOneofsOptionKey: int64(1),
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" declares 3 oneofs, exceeding the max of 1",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 0,
+					EndLine:     47,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestMaxOneofsWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/max_oneofs"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestMaxEntityFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/max_entity_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				maxEntityFieldsOptionKey: int64(5),
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" has 7 fields, exceeding the max of 5",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 0,
+					EndLine:     38,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestMaxEntityFieldsWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/max_entity_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestImmutableFieldsFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/immutable_fields_first"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				immutableFieldsFirstOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" declares mutable \"title\" before immutable \"created_at\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 4,
+					EndLine:     34,
+					EndColumn:   45,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestImmutableFieldsFirstWithImmutableEntityFields(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/immutable_fields_first"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				immutableFieldsFirstOptionKey:  true,
+				immutableEntityFieldsOptionKey: []string{"id", "account_id"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestImmutableFieldsFirstWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/immutable_fields_first"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestSoftDeleteFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/soft_delete"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" has deleted_at but no expire_time",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 0,
+					EndLine:     36,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestSoftDeleteWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/soft_delete"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				softDeleteCompanionFieldOptionKey: "deletion_policy",
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" has deleted_at but no deletion_policy",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 0,
+					EndLine:     36,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Author\" has deleted_at but no deletion_policy",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   39,
+					StartColumn: 0,
+					EndLine:     46,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestVerbFieldNamesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/verb_field_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				verbFieldNamesOptionKey: []string{"create", "process"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"process\" looks like a verb; entity fields should be nouns",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   27,
+					StartColumn: 4,
+					EndLine:     27,
+					EndColumn:   21,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestVerbFieldNamesWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/verb_field_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestPluralRepeatedFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/plural_repeated_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "repeated field \"tag\" should be plural \"tags\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 4,
+					EndLine:     25,
+					EndColumn:   28,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "repeated field \"isbn\" should be plural \"isbns\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   31,
+					StartColumn: 4,
+					EndLine:     31,
+					EndColumn:   29,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestPluralRepeatedFieldsWithExceptions(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/plural_repeated_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				pluralFieldExceptionsOptionKey: []string{"isbn"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "repeated field \"tag\" should be plural \"tags\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 4,
+					EndLine:     25,
+					EndColumn:   28,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestAffirmativeBoolFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/affirmative_bool_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "bool field \"is_disabled\" should use affirmative naming like \"enabled\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 4,
+					EndLine:     25,
+					EndColumn:   25,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "bool field \"not_published\" should use affirmative naming like \"published\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   27,
+					StartColumn: 4,
+					EndLine:     27,
+					EndColumn:   27,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestAffirmativeBoolFieldsWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/affirmative_bool_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				negativeBoolPrefixesOptionKey: []string{"un_"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "bool field \"is_disabled\" should use affirmative naming like \"enabled\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   25,
+					StartColumn: 4,
+					EndLine:     25,
+					EndColumn:   25,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "bool field \"un_archived\" should use affirmative naming like \"archived\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   31,
+					StartColumn: 4,
+					EndLine:     31,
+					EndColumn:   25,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestWrapperTypeFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/wrapper_type_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"id\" uses StringValue; use a plain string",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   39,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestRequireEtagFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/require_etag"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				requireEtagOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [etag]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   29,
+					StartColumn: 0,
+					EndLine:     34,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestRequireEtagWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/require_etag"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestEntityCompositeKeysFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_composite_keys"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityCompositeKeysRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityCompositeKeysRuleID,
+				Message: "entity \"Membership\" appears to use a composite key; prefer a single id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   46,
+					StartColumn: 0,
+					EndLine:     57,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		entityCompositeKeysRuleID: 1,
+	})
+}
+
+func TestEntityCompositeKeysIdentifierField(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_composite_keys"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityCompositeKeysRuleID},
+			Options: map[string]any{
+				identifierFieldOptionKey: "name",
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestStrictProfile(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_enum_presence"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				pluginutil.ProfileOptionKey:          "strict",
+				requiredCreateRequestFieldsOptionKey: []string{"account_id", "request_id", "format"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request field \"account_id\" should be marked REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   20,
+					StartColumn: 4,
+					EndLine:     20,
+					EndColumn:   26,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "enum field \"format\" in request should document unspecified handling",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   26,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "required enum field \"format\" can be silently BOOK_FORMAT_UNSPECIFIED, consider declaring it in a oneof or using google.api.field_behavior = REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   26,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request field \"account_id\" should be marked REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 4,
+					EndLine:     30,
+					EndColumn:   26,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "enum field \"format\" in request should document unspecified handling",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   32,
+					StartColumn: 4,
+					EndLine:     32,
+					EndColumn:   35,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 5,
+	})
+}
+
+// TestStrictProfileOptions checks that the strict profile enables all of the
+// optional checks toggled by a bool option, so new ones aren't left out of it.
+func TestStrictProfileOptions(t *testing.T) {
+	t.Parallel()
+
+	// nonOptInOptionKeys configure the checks with a value, or relax them,
+	// rather than enabling an optional check.
+	nonOptInOptionKeys := []string{
+		pluginutil.ProfileOptionKey,
+		requiredEntityFieldsOptionKey,
+		requiredRequestFieldsOptionKey,
+		requiredCreateRequestFieldsOptionKey,
+		requestIdentifierOneofOptionKey,
+		maxRequestFieldsOptionKey,
+		timestampFieldAliasesOptionKey,
+		lifecycleMethodPrefixesOptionKey,
+		resourceTypePatternOptionKey,
+		timestampConventionOptionKey,
+		maxOneofsOptionKey,
+		maxEntityFieldsOptionKey,
+		disablePreferredFieldNamesOptionKey,
+		identifierFieldOptionKey,
+		servicesOptionKey,
+		pluralFieldExceptionsOptionKey,
+		negativeBoolPrefixesOptionKey,
+		hotEntityFieldsOptionKey,
+		immutableEntityFieldsOptionKey,
+		softDeleteCompanionFieldOptionKey,
+		verbFieldNamesOptionKey,
+		bulkMethodFieldsOptionKey,
+	}
+	for _, key := range optionKeys {
+		if slices.Contains(nonOptInOptionKeys, key) {
+			continue
+		}
+		if value, found := profiles["strict"][key]; !found || value != true {
+			t.Errorf("option %q is not enabled by the strict profile", key)
+		}
+	}
+}
+
+func TestLenientProfile(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_enum_presence"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				pluginutil.ProfileOptionKey:          "lenient",
+				requiredCreateRequestFieldsOptionKey: []string{"account_id", "request_id", "format"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestRepeatedTimestampFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/repeated_timestamp"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"created_at\" should not be repeated",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   23,
+					StartColumn: 4,
+					EndLine:     23,
+					EndColumn:   54,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestRequiredEntityFieldsExtensionFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_entity_fields_extension"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [labels]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   32,
+					StartColumn: 0,
+					EndLine:     37,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestRequiredEntityFieldsExtensionMergedWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_entity_fields_extension"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				requiredEntityFieldsOptionKey: []string{"id", "etag"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [etag labels]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   32,
+					StartColumn: 0,
+					EndLine:     37,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Author\" is missing required fields: [etag]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   39,
+					StartColumn: 0,
+					EndLine:     45,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestMethodPluralizationFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/method_pluralization"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{methodPluralizationRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  methodPluralizationRuleID,
+				Message: "method \"ListBook\" uses singular but should be plural",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   7,
+					StartColumn: 4,
+					EndLine:     8,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		methodPluralizationRuleID: 1,
+	})
+}
+
+func TestDocumentRequestEnumsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/document_request_enums"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				documentRequestEnumsOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "enum field \"status\" in request should document unspecified handling",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   26,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestDocumentRequestEnumsWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/document_request_enums"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestLifecycleMethodsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/lifecycle_methods"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID, requiredRequestFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [name]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 0,
+					EndLine:     34,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "message \"RestoreBookRequest\" is missing required fields: [book_id]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   13,
+					StartColumn: 0,
+					EndLine:     15,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID:  1,
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestLifecycleMethodPrefixesOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/lifecycle_methods"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				lifecycleMethodPrefixesOptionKey: []string{"Archive"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestSiblingAccountIDFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/sibling_account_id"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{siblingAccountIDRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  siblingAccountIDRuleID,
+				Message: "GetBookRequest is missing account_id that its sibling requests require",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   21,
+					StartColumn: 0,
+					EndLine:     23,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		siblingAccountIDRuleID: 1,
+	})
+}
+
+func TestCollectionMethodNamesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/collection_method_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{collectionMethodNamesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  collectionMethodNamesRuleID,
+				Message: "method \"GetBooks\" returns a collection but isn't named List/Search",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   7,
+					StartColumn: 4,
+					EndLine:     8,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		collectionMethodNamesRuleID: 1,
+	})
+}
+
+func TestCollectionMethodNamesOverlap(t *testing.T) {
+	t.Parallel()
+
+	// GetBooks is only reported by the rule checking the prefix, rather than
+	// also by the one checking the whole name (ListBooks).
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/collection_method_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{collectionMethodNamesRuleID, collectionMethodEntityNamesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  collectionMethodNamesRuleID,
+				Message: "method \"GetBooks\" returns a collection but isn't named List/Search",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   7,
+					StartColumn: 4,
+					EndLine:     8,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+	checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/collection_method_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{collectionMethodEntityNamesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  collectionMethodEntityNamesRuleID,
+				Message: "method \"GetBooks\" should be \"ListBooks\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   7,
+					StartColumn: 4,
+					EndLine:     8,
+					EndColumn:   5,
+				},
+			},
+		},
+	}.Run(t)
+}
+
+func TestCollectionMethodEntityNamesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/collection_method_entity_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{collectionMethodEntityNamesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  collectionMethodEntityNamesRuleID,
+				Message: "method \"ListAllBooks\" should be \"ListBooks\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   12,
+					StartColumn: 4,
+					EndLine:     13,
+					EndColumn:   5,
+				},
+			},
+			{
+				RuleID:  collectionMethodEntityNamesRuleID,
+				Message: "method \"GetBookList\" should be \"ListBooks\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     16,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		collectionMethodEntityNamesRuleID: 2,
+	})
+}
+
+func TestCollectionMethodEntityNamesBulkMethods(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/bulk_method_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{collectionMethodEntityNamesRuleID},
+		},
+		Spec: Spec,
+		// No expected annotations - bulk methods (e.g: ImportBooks) aren't collection methods
+	}, nil)
+}
+
+func TestStrictOptionsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID, requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				pluginutil.StrictOptionsOptionKey: true,
+				"requiredentityfields":            []string{"id"},
+				lifecycleMethodPrefixesOptionKey:  []string{"Restore"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "unknown option \"requiredentityfields\"",
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestUnknownOptionsWithoutStrictOptions(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID, requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				"requiredentityfields": []string{"id"},
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestMixedFieldCasingFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/field_casing"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" mixes snake_case and camelCase field names",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   29,
+					StartColumn: 0,
+					EndLine:     35,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestForbidCamelCaseFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/field_casing"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				forbidCamelCaseFieldsOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "entity \"Book\" mixes snake_case and camelCase field names",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   29,
+					StartColumn: 0,
+					EndLine:     35,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"pageCount\" should be snake_case",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 4,
+					EndLine:     34,
+					EndColumn:   25,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 2,
+	})
+}
+
+func TestEntityResourceTypeFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_resource_type"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityResourceTypeRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityResourceTypeRuleID,
+				Message: "entity \"Author\" is missing google.api.resource annotation",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   49,
+					StartColumn: 0,
+					EndLine:     54,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  entityResourceTypeRuleID,
+				Message: "entity \"Shelf\" resource type \"library.example.com/Shelf\" doesn't match \"^qdrant\\\\.cloud/Shelf$\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   56,
+					StartColumn: 0,
+					EndLine:     63,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		entityResourceTypeRuleID: 2,
+	})
+}
+
+func TestEntityResourceTypePatternOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_resource_type"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityResourceTypeRuleID},
+			Options: map[string]any{
+				resourceTypePatternOptionKey: `^[a-z.]+/{entity}$`,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityResourceTypeRuleID,
+				Message: "entity \"Author\" is missing google.api.resource annotation",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   49,
+					StartColumn: 0,
+					EndLine:     54,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		entityResourceTypeRuleID: 1,
+	})
+}
+
+func TestServicelessEntitiesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/serviceless_entities"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{servicelessEntitiesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  servicelessEntitiesRuleID,
+				Message: "file defines entity-like messages but no services",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName: "simple.proto",
+				},
+			},
+		},
+	}, map[string]int{
+		servicelessEntitiesRuleID: 1,
+	})
+}
+
+func TestServicelessEntitiesSuccess(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/simple_success"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{servicelessEntitiesRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestQdrantTimestampConvention(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/timestamp_convention"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Author\" is missing required fields: [created_at]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   37,
+					StartColumn: 0,
+					EndLine:     43,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 1,
+	})
+}
+
+func TestAIPTimestampConvention(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/timestamp_convention"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredEntityFieldsRuleID},
+			Options: map[string]any{
+				timestampConventionOptionKey: "aip",
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "message \"Book\" is missing required fields: [create_time]",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   29,
+					StartColumn: 0,
+					EndLine:     35,
+					EndColumn:   1,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"created_at\" is discouraged, use \"create_time\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   33,
+					StartColumn: 4,
+					EndLine:     33,
+					EndColumn:   45,
+				},
+			},
+			{
+				RuleID:  requiredEntityFieldsRuleID,
+				Message: "field \"last_modified_at\" is discouraged, use \"update_time\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 4,
+					EndLine:     34,
+					EndColumn:   51,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredEntityFieldsRuleID: 3,
+	})
+}
+
+func TestUnknownTimestampConvention(t *testing.T) {
+	t.Parallel()
+
+	request, err := (&checktest.RequestSpec{
+		Files: &checktest.ProtoFileSpec{
+			DirPaths:  []string{"testdata/timestamp_convention"},
+			FilePaths: []string{"simple.proto"},
+		},
+		Options: map[string]any{
+			timestampConventionOptionKey: "google",
+		},
+	}).ToRequest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTimestampConvention(request); err == nil {
+		t.Error("expected an error for an unknown timestamp convention")
+	}
+}
+
+func TestUnusedRequestsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/unused_requests"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{unusedRequestsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  unusedRequestsRuleID,
+				Message: "request message \"LegacyRequest\" is not used by any RPC",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   17,
+					StartColumn: 0,
+					EndLine:     19,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		unusedRequestsRuleID: 1,
+	})
+}
+
+func TestListResponseNamesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/list_response_names"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{listResponseNamesRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  listResponseNamesRuleID,
+				Message: "ListBooksRequest has no matching ListBooksResponse",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 0,
+					EndLine:     16,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		listResponseNamesRuleID: 1,
+	})
+}
+
+func TestUnexposedEntityFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/unexposed_entity_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{unexposedEntityFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  unexposedEntityFieldsRuleID,
+				Message: "entity field \"Book.internal_notes\" is never exposed by any RPC",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   45,
+					StartColumn: 4,
+					EndLine:     45,
+					EndColumn:   30,
+				},
+			},
+		},
+	}, map[string]int{
+		unexposedEntityFieldsRuleID: 1,
+	})
+}
+
+func TestEnumAllowAliasFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/enum_allow_alias"},
+				FilePaths: []string{"simple.proto"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  enumAllowAliasRuleID,
+				Message: "enum \"Format\" sets allow_alias without justification",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   13,
+					StartColumn: 0,
+					EndLine:     18,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		enumAllowAliasRuleID: 1,
+	})
+}
+
+func TestCreateResponseEntityFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/create_response_entity"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{createResponseEntityRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  createResponseEntityRuleID,
+				Message: "CreateBook should return the created Book",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   8,
+					StartColumn: 4,
+					EndLine:     9,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		createResponseEntityRuleID: 1,
+	})
+}
+
+func TestEntityIdentifierBehaviorFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_identifier_behavior"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityIdentifierBehaviorRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityIdentifierBehaviorRuleID,
+				Message: "entity \"Author\" id should be marked IDENTIFIER",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   40,
+					StartColumn: 4,
+					EndLine:     40,
+					EndColumn:   62,
+				},
+			},
+		},
+	}, map[string]int{
+		entityIdentifierBehaviorRuleID: 1,
+	})
+}
+
+func TestEntityIdentifierBehaviorWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_identifier_behavior"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityIdentifierBehaviorRuleID},
+			Options: map[string]any{
+				identifierFieldOptionKey: "name",
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityIdentifierBehaviorRuleID,
+				Message: "entity \"Book\" name should be marked IDENTIFIER",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 4,
+					EndLine:     34,
+					EndColumn:   20,
+				},
+			},
+			{
+				RuleID:  entityIdentifierBehaviorRuleID,
+				Message: "entity \"Author\" name should be marked IDENTIFIER",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   42,
+					StartColumn: 4,
+					EndLine:     42,
+					EndColumn:   20,
+				},
+			},
+		},
+	}, map[string]int{
+		entityIdentifierBehaviorRuleID: 2,
+	})
+}
+
+func TestEntityNameIdentifierFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_name_identifier"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityNameIdentifierRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  entityNameIdentifierRuleID,
+				Message: "entity \"Book\" marks \"name\" as IDENTIFIER; use \"id\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   34,
+					StartColumn: 4,
+					EndLine:     34,
+					EndColumn:   63,
+				},
+			},
+		},
+	}, map[string]int{
+		entityNameIdentifierRuleID: 1,
+	})
+}
+
+func TestEntityNameIdentifierWithIdentifierFieldOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/entity_name_identifier"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{entityNameIdentifierRuleID},
+			Options: map[string]any{
+				identifierFieldOptionKey: "name",
+			},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestUpdateImmutableFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/update_immutable_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{updateImmutableFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  updateImmutableFieldsRuleID,
+				Message: "UpdateBookRequest exposes immutable field \"account_id\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   17,
+					StartColumn: 4,
+					EndLine:     17,
+					EndColumn:   18,
+				},
+			},
+			{
+				RuleID:  updateImmutableFieldsRuleID,
+				Message: "UpdateAuthorRequest exposes immutable field \"created_at\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   27,
+					StartColumn: 4,
+					EndLine:     27,
+					EndColumn:   22,
+				},
+			},
+		},
+	}, map[string]int{
+		updateImmutableFieldsRuleID: 2,
+	})
+}
+
+func TestUpdateImmutableFieldsWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/update_immutable_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{updateImmutableFieldsRuleID},
+			Options: map[string]any{
+				immutableEntityFieldsOptionKey: []string{"id", "name"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  updateImmutableFieldsRuleID,
+				Message: "UpdateBookRequest exposes immutable field \"name\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   17,
+					StartColumn: 4,
+					EndLine:     17,
+					EndColumn:   18,
+				},
+			},
+			{
+				RuleID:  updateImmutableFieldsRuleID,
+				Message: "UpdateAuthorRequest exposes immutable field \"name\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   27,
+					StartColumn: 4,
+					EndLine:     27,
+					EndColumn:   22,
+				},
+			},
+		},
+	}, map[string]int{
+		updateImmutableFieldsRuleID: 2,
+	})
+}
+
+func TestStreamingPaginationFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/streaming_pagination"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{streamingPaginationRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  streamingPaginationRuleID,
+				Message: "streaming method \"StreamBooks\" should not use pagination fields",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   8,
+					StartColumn: 4,
+					EndLine:     9,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		streamingPaginationRuleID: 1,
+	})
+}
+
+func TestNestedRequestMessagesFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/nested_request_messages"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request \"UpdateBookRequest\" should not define nested messages",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   14,
+					StartColumn: 0,
+					EndLine:     21,
+					EndColumn:   1,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestRequireFieldBehaviorOnIDsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_field_behavior_on_ids"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				requireFieldBehaviorOnIDsOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request field \"book_id\" should be marked REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   17,
+					StartColumn: 4,
+					EndLine:     17,
+					EndColumn:   23,
+				},
+			},
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request field \"account_id\" should be marked REQUIRED",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   26,
+					StartColumn: 4,
+					EndLine:     26,
+					EndColumn:   68,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 2,
+	})
+}
+
+func TestRequireFieldBehaviorOnIDsWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/required_field_behavior_on_ids"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestAccountIDOrderFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_order"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+			Options: map[string]any{
+				accountIDFirstOptionKey: true,
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  requiredRequestFieldsRuleID,
+				Message: "request \"GetBookRequest\" declares book_id before account_id",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   16,
+					StartColumn: 4,
+					EndLine:     16,
+					EndColumn:   26,
+				},
+			},
+		},
+	}, map[string]int{
+		requiredRequestFieldsRuleID: 1,
+	})
+}
+
+func TestAccountIDOrderWithoutOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_order"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{requiredRequestFieldsRuleID},
+		},
+		Spec: Spec,
+	}, nil)
+}
+
+func TestAccountIDJSONNameFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_json_name"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{accountIDJSONNameRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  accountIDJSONNameRuleID,
+				Message: "account_id field has unexpected json_name \"acctId\"",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   13,
+					StartColumn: 4,
+					EndLine:     13,
+					EndColumn:   49,
+				},
+			},
+		},
+	}, map[string]int{
+		accountIDJSONNameRuleID: 1,
+	})
+}
+
+func TestAccountIDTerminologyFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/account_id_terminology"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{accountIDTerminologyRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  accountIDTerminologyRuleID,
+				Message: "field \"org_id\" is discouraged, use \"account_id\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   15,
+					StartColumn: 4,
+					EndLine:     15,
+					EndColumn:   22,
+				},
+			},
+			{
+				RuleID:  accountIDTerminologyRuleID,
+				Message: "field \"organization_id\" is discouraged, use \"account_id\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   28,
+					StartColumn: 4,
+					EndLine:     28,
+					EndColumn:   31,
+				},
+			},
+			{
+				RuleID:  accountIDTerminologyRuleID,
+				Message: "field \"tenant_id\" is discouraged, use \"account_id\" instead",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   30,
+					StartColumn: 4,
+					EndLine:     30,
+					EndColumn:   25,
+				},
+			},
+		},
+	}, map[string]int{
+		accountIDTerminologyRuleID: 3,
+	})
+}
+
+func TestDeleteResponseConsistencyFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/delete_response_consistency"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{deleteResponseConsistencyRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  deleteResponseConsistencyRuleID,
+				Message: "DeleteBook returns Empty but DeleteAuthor returns Author; be consistent",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   11,
+					StartColumn: 4,
+					EndLine:     12,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		deleteResponseConsistencyRuleID: 1,
+	})
+}
+
+func TestHotFieldNumbersFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/hot_field_numbers"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{hotFieldNumbersRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  hotFieldNumbersRuleID,
+				Message: "field \"account_id\" uses number 20; keep hot fields in 1-15",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   22,
+					StartColumn: 4,
+					EndLine:     22,
+					EndColumn:   27,
+				},
+			},
+		},
+	}, map[string]int{
+		hotFieldNumbersRuleID: 1,
+	})
+}
+
+func TestHotFieldNumbersWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/hot_field_numbers"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{hotFieldNumbersRuleID},
+			Options: map[string]any{
+				hotEntityFieldsOptionKey: []string{"description"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  hotFieldNumbersRuleID,
+				Message: "field \"description\" uses number 16; keep hot fields in 1-15",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   26,
+					StartColumn: 4,
+					EndLine:     26,
+					EndColumn:   28,
+				},
+			},
+		},
+	}, map[string]int{
+		hotFieldNumbersRuleID: 1,
+	})
+}
+
+func TestBooleanEnumsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/boolean_enums"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{booleanEnumsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  booleanEnumsRuleID,
+				Message: "field \"enabled_status\" uses a two-value enum; consider a bool",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   26,
+					StartColumn: 4,
+					EndLine:     26,
+					EndColumn:   37,
+				},
+			},
+		},
+	}, map[string]int{
+		booleanEnumsRuleID: 1,
+	})
+}
+
+func TestBulkMethodFieldsFailure(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/bulk_method_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{bulkMethodFieldsRuleID},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  bulkMethodFieldsRuleID,
+				Message: "ImportBooks uses the Import prefix but lacks a source field",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   10,
+					StartColumn: 4,
+					EndLine:     11,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		bulkMethodFieldsRuleID: 1,
+	})
+}
+
+func TestBulkMethodFieldsWithOption(t *testing.T) {
+	t.Parallel()
+
+	assertAnnotationCounts(t, checktest.CheckTest{
+		Request: &checktest.RequestSpec{
+			Files: &checktest.ProtoFileSpec{
+				DirPaths:  []string{"testdata/bulk_method_fields"},
+				FilePaths: []string{"simple.proto"},
+			},
+			RuleIDs: []string{bulkMethodFieldsRuleID},
+			Options: map[string]any{
+				bulkMethodFieldsOptionKey: []string{"Import=uri", "Export=destination,format"},
+			},
+		},
+		Spec: Spec,
+		ExpectedAnnotations: []checktest.ExpectedAnnotation{
+			{
+				RuleID:  bulkMethodFieldsRuleID,
+				Message: "ExportBooks uses the Export prefix but lacks a format field",
+				FileLocation: &checktest.ExpectedFileLocation{
+					FileName:    "simple.proto",
+					StartLine:   13,
+					StartColumn: 4,
+					EndLine:     14,
+					EndColumn:   5,
+				},
+			},
+		},
+	}, map[string]int{
+		bulkMethodFieldsRuleID: 1,
+	})
+}
+
+// reservedMessageDescriptor is a crafted message descriptor, with the reserved
+// names and numbers of another message.
+type reservedMessageDescriptor struct {
+	protoreflect.MessageDescriptor
+	reserved protoreflect.MessageDescriptor
+}
+
+func (m reservedMessageDescriptor) ReservedNames() protoreflect.Names {
+	return m.reserved.ReservedNames()
+}
+
+func (m reservedMessageDescriptor) ReservedRanges() protoreflect.FieldRanges {
+	return m.reserved.ReservedRanges()
+}
+
+func TestReservedFieldsValidator(t *testing.T) {
+	t.Parallel()
+
+	fileDescriptors, err := (&checktest.ProtoFileSpec{
+		DirPaths:  []string{"testdata/reserved_fields"},
+		FilePaths: []string{"simple.proto"},
+	}).ToFileDescriptors(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := fileDescriptors[0].ProtoreflectFileDescriptor().Messages()
+	book := messages.ByName("Book")
+	for _, tc := range []struct {
+		message         protoreflect.MessageDescriptor
+		expectedMessage string
+	}{
+		{message: book},
+		{message: reservedMessageDescriptor{book, messages.ByName("ReservedName")}, expectedMessage: "field \"legacy\" reuses a reserved name"},
+		{message: reservedMessageDescriptor{book, messages.ByName("ReservedNumber")}, expectedMessage: "field \"legacy\" reuses reserved number 2"},
+	} {
+		var message string
+		if err := reservedFieldsValidator()(tc.message, nil); err != nil {
+			message = err.Message
+		}
+		if message != tc.expectedMessage {
+			t.Errorf("reservedFieldsValidator() = %q, expected %q", message, tc.expectedMessage)
+		}
+	}
+}